@@ -2,12 +2,9 @@ package cmd
 
 import (
 	"fmt"
-	"net/http"
-	"os"
 	"strings"
 	"time"
 
-	"github.com/self-actuated/actuated-cli/pkg"
 	"github.com/spf13/cobra"
 )
 
@@ -46,7 +43,7 @@ func runAgentLogsE(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	staff, err := cmd.Flags().GetBool("staff")
+	staff, err := getStaff(cmd)
 	if err != nil {
 		return err
 	}
@@ -55,6 +52,7 @@ func runAgentLogsE(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	owner = getOwner(owner)
 
 	age, err := cmd.Flags().GetDuration("age")
 	if err != nil {
@@ -73,16 +71,15 @@ func runAgentLogsE(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("pat is required")
 	}
 
-	c := pkg.NewClient(http.DefaultClient, os.Getenv("ACTUATED_URL"))
-
-	res, status, err := c.GetAgentLogs(pat, owner, host, age, staff)
-
+	c, err := newClient(cmd)
 	if err != nil {
 		return err
 	}
 
-	if status != http.StatusAccepted {
-		return fmt.Errorf("unexpected status code: %d, body: %s", status, res)
+	res, _, err := c.GetAgentLogs(cmd.Context(), pat, owner, host, age, staff)
+
+	if err != nil {
+		return err
 	}
 
 	fmt.Println(res)
@@ -5,119 +5,341 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
-	"path"
+	"os/exec"
+	"runtime"
 	"time"
 
 	"github.com/spf13/cobra"
 )
 
+// githubClientID is the OAuth app used for the device flow
+const githubClientID = "8c5dc5d9750ff2a8396a"
+
 func makeAuth() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "auth",
 		Short: "Authenticate to GitHub to obtain a token and save it to $HOME/.actuated/PAT",
+		Long: `Authenticate to GitHub with the device flow to obtain a token, which is
+saved to $HOME/.actuated/PAT, or the file given by --token, with permissions
+of 0600.
+
+Add --encrypt to encrypt the token with a passphrase, set through the
+ACTUATED_TOKEN_PASSPHRASE environment variable. The same variable must be
+set to use the token with other commands.`,
+		Example: `  # Authenticate and save the token
+  actuated-cli auth
+
+  # Authenticate from a headless machine, without opening a browser
+  actuated-cli auth --no-browser
+
+  # Authenticate to GitHub Enterprise Server with your own OAuth app
+  actuated-cli auth --github-host github.example.com --client-id CLIENT_ID
+
+  # Authenticate and save the token encrypted with a passphrase
+  export ACTUATED_TOKEN_PASSPHRASE=...
+  actuated-cli auth --encrypt
+
+  # Check the token and its scopes
+  actuated-cli auth status
+
+  # Print the token for use with another tool
+  actuated-cli auth token
+
+  # Remove the saved token
+  actuated-cli auth logout
+`,
+	}
+
+	// Authentication does not need ACTUATED_URL, but the active context
+	// may give the path of the token file
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return loadActiveContext(cmd)
 	}
 
 	cmd.RunE = runAuthE
 
+	cmd.Flags().Bool("encrypt", false, "Encrypt the token with the passphrase from ACTUATED_TOKEN_PASSPHRASE")
+	cmd.Flags().String("client-id", githubClientID, "OAuth app client ID, required for GitHub Enterprise Server")
+	cmd.Flags().Bool("no-browser", false, "Do not open the verification URL in a browser")
+	cmd.Flags().Bool("print-code", false, "Print only the user code to stdout, for headless machines, implies --no-browser")
+
+	cmd.AddCommand(makeAuthStatus())
+	cmd.AddCommand(makeAuthToken())
+	cmd.AddCommand(makeAuthLogout())
+
 	return cmd
 }
 
 func runAuthE(cmd *cobra.Command, args []string) error {
 
-	token := ""
+	encrypt, err := cmd.Flags().GetBool("encrypt")
+	if err != nil {
+		return err
+	}
+
+	noBrowser, err := cmd.Flags().GetBool("no-browser")
+	if err != nil {
+		return err
+	}
+
+	printCode, err := cmd.Flags().GetBool("print-code")
+	if err != nil {
+		return err
+	}
+
+	patFile, err := patFilePath(cmd)
+	if err != nil {
+		return err
+	}
+
+	// Fail before the device flow if the token can't be encrypted
+	if encrypt {
+		if _, err := tokenPassphrase(); err != nil {
+			return fmt.Errorf("set %s to a passphrase to encrypt the token", passphraseEnv)
+		}
+	}
+
+	token, err := deviceFlowLogin(cmd, deviceFlowOptions{
+		OpenBrowser: !noBrowser && !printCode,
+		PrintCode:   printCode,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := writePatFile(patFile, token, encrypt); err != nil {
+		return err
+	}
+
+	if printCode {
+		fmt.Fprintf(os.Stderr, "Access token written to: %s\n", patFile)
+	} else {
+		fmt.Printf("Access token written to: %s\n", patFile)
+	}
+
+	return nil
+}
+
+// deviceFlowOptions control how the verification code is shown.
+type deviceFlowOptions struct {
+	// OpenBrowser opens the verification URI in a browser
+	OpenBrowser bool
+
+	// PrintCode prints only the user code to stdout, and everything else
+	// to stderr, so that the code can be relayed from a headless machine
+	PrintCode bool
+}
+
+// deviceFlowLogin obtains a token from GitHub with the OAuth 2.0 device
+// authorization grant (RFC 8628).
+func deviceFlowLogin(cmd *cobra.Command, opts deviceFlowOptions) (string, error) {
+	ctx := cmd.Context()
+	httpClient, err := newHTTPClient(cmd)
+	if err != nil {
+		return "", err
+	}
+
+	host := githubHost(cmd)
+	clientID := oauthClientID(cmd)
+
+	if isEnterpriseHost(host) && clientID == githubClientID {
+		return "", fmt.Errorf("register an OAuth app with the device flow enabled on %s, then give its client ID with --client-id", host)
+	}
+
+	auth, err := requestDeviceCode(ctx, httpClient, host, clientID)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.PrintCode {
+		fmt.Fprintf(os.Stderr, "Please visit: %s and enter the code below\n", auth.VerificationURI)
+		fmt.Println(auth.UserCode)
+	} else {
+		fmt.Printf("Please visit: %s\n", auth.VerificationURI)
+		fmt.Printf("and enter the code: %s\n", auth.UserCode)
+	}
+
+	if opts.OpenBrowser {
+		if err := openBrowser(auth.VerificationURI); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to open a browser: %s\n", err)
+		}
+	}
+
+	return pollDeviceToken(ctx, httpClient, host, clientID, auth, func() {
+		if !opts.PrintCode {
+			fmt.Println("Waiting for authorization...")
+		}
+	})
+}
+
+// oauthClientID returns the OAuth app from --client-id, or the
+// github-client-id of the active context, or the actuated OAuth app.
+func oauthClientID(cmd *cobra.Command) string {
+	if f := cmd.Flags().Lookup("client-id"); f != nil && f.Changed {
+		return f.Value.String()
+	}
 
-	clientID := "8c5dc5d9750ff2a8396a"
+	if activeContext != nil && len(activeContext.GitHubClientID) > 0 {
+		return activeContext.GitHubClientID
+	}
 
+	return githubClientID
+}
+
+// requestDeviceCode starts the device flow, the user code from the result
+// is entered at the verification URI.
+func requestDeviceCode(ctx context.Context, httpClient *http.Client, host, clientID string) (*DeviceAuth, error) {
 	dcParams := url.Values{}
 	dcParams.Set("client_id", clientID)
-	dcParams.Set("redirect_uri", "http://127.0.0.1:31111/oauth/callback")
 	dcParams.Set("scope", "read:user,read:org,user:email")
 
-	req, err := http.NewRequest(http.MethodPost, "https://github.com/login/device/code", bytes.NewBuffer([]byte(dcParams.Encode())))
-
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://"+host+"/login/device/code", bytes.NewBuffer([]byte(dcParams.Encode())))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := httpClient.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer res.Body.Close()
 
 	body, _ := io.ReadAll(res.Body)
 
 	if res.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d, body: %s", res.StatusCode, string(body))
+		return nil, fmt.Errorf("unexpected status code: %d, body: %s", res.StatusCode, string(body))
 	}
 
-	auth := DeviceAuth{}
+	auth := &DeviceAuth{}
+	if err := json.Unmarshal(body, auth); err != nil {
+		return nil, err
+	}
 
-	if err := json.Unmarshal(body, &auth); err != nil {
-		return err
+	if len(auth.Error) > 0 {
+		return nil, auth.Err()
+	}
+
+	return auth, nil
+}
+
+// pollDeviceToken polls for the access token at the interval given by the
+// server until the user authorizes the device, denies it, or the device
+// code expires. onWait is called each time the authorization is pending.
+func pollDeviceToken(ctx context.Context, httpClient *http.Client, host, clientID string, auth *DeviceAuth, onWait func()) (string, error) {
+	interval := time.Duration(auth.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultDeviceInterval
+	}
+
+	expiresIn := time.Duration(auth.ExpiresIn) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = defaultDeviceExpiry
 	}
+	deadline := time.Now().Add(expiresIn)
 
-	fmt.Printf("Please visit: %s\n", auth.VerificationURI)
-	fmt.Printf("and enter the code: %s\n", auth.UserCode)
+	for {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(interval):
+		}
+
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("the device code expired after %s, run \"actuated-cli auth\" to try again", expiresIn)
+		}
 
-	for i := 0; i < 60; i++ {
 		urlv := url.Values{}
 		urlv.Set("client_id", clientID)
 		urlv.Set("device_code", auth.DeviceCode)
-
 		urlv.Set("grant_type", "urn:ietf:params:oauth:grant-type:device_code")
-		req.Header.Set("Accept", "application/json")
-		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-		req, err := http.NewRequest(http.MethodPost, "https://github.com/login/oauth/access_token", bytes.NewBuffer([]byte(urlv.Encode())))
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://"+host+"/login/oauth/access_token", bytes.NewBuffer([]byte(urlv.Encode())))
 		if err != nil {
-			return err
+			return "", err
 		}
 
-		res, err := http.DefaultClient.Do(req)
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		res, err := httpClient.Do(req)
 		if err != nil {
-			return err
+			return "", err
 		}
 
 		body, _ := io.ReadAll(res.Body)
-		parts, err := url.ParseQuery(string(body))
-		if err != nil {
-			return err
+		res.Body.Close()
+
+		token := DeviceToken{}
+		if err := json.Unmarshal(body, &token); err != nil {
+			return "", fmt.Errorf("unexpected response from GitHub, status code: %d, body: %s", res.StatusCode, string(body))
 		}
-		if parts.Get("error") == "authorization_pending" {
-			fmt.Println("Waiting for authorization...")
-			time.Sleep(time.Second * 5)
-			continue
-		} else if parts.Get("access_token") != "" {
-			// fmt.Println(parts)
-			token = parts.Get("access_token")
-
-			break
-		} else {
-			return fmt.Errorf("something went wrong")
+
+		switch token.Error {
+		case "":
+			if len(token.AccessToken) == 0 {
+				return "", fmt.Errorf("no access token in the response from GitHub")
+			}
+			return token.AccessToken, nil
+		case "authorization_pending":
+			onWait()
+		case "slow_down":
+			// RFC 8628 section 3.5: increase the interval by 5 seconds,
+			// unless the server gives a new interval
+			if token.Interval > 0 {
+				interval = time.Duration(token.Interval) * time.Second
+			} else {
+				interval += time.Second * 5
+			}
+			onWait()
+		case "expired_token":
+			return "", fmt.Errorf("the device code expired, run \"actuated-cli auth\" to try again")
+		case "access_denied":
+			return "", fmt.Errorf("authorization was denied by the user")
+		default:
+			return "", token.Err()
 		}
 	}
+}
 
-	const basePath = "$HOME/.actuated"
-	os.Mkdir(os.ExpandEnv(basePath), 0755)
+// openBrowser opens the URL with the default browser for the OS.
+func openBrowser(u string) error {
+	var c *exec.Cmd
 
-	if err := os.WriteFile(os.ExpandEnv(path.Join(basePath, "PAT")), []byte(token), 0644); err != nil {
-		return err
+	switch runtime.GOOS {
+	case "darwin":
+		c = exec.Command("open", u)
+	case "windows":
+		c = exec.Command("rundll32", "url.dll,FileProtocolHandler", u)
+	default:
+		if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+			return fmt.Errorf("no display found, use --no-browser on headless machines")
+		}
+		c = exec.Command("xdg-open", u)
 	}
 
-	fmt.Printf("Access token written to: %s\n", os.ExpandEnv(path.Join(basePath, "PAT")))
-
-	return nil
+	return c.Start()
 }
 
+const (
+	// defaultDeviceInterval is the polling interval from RFC 8628 when
+	// the server does not give one
+	defaultDeviceInterval = time.Second * 5
+
+	// defaultDeviceExpiry is GitHub's lifetime for a device code
+	defaultDeviceExpiry = time.Minute * 15
+)
+
 // DeviceAuth is the device auth response from GitHub and is
 // used to exchange for a personal access token
 type DeviceAuth struct {
@@ -126,4 +348,35 @@ type DeviceAuth struct {
 	VerificationURI string `json:"verification_uri"`
 	ExpiresIn       int    `json:"expires_in"`
 	Interval        int    `json:"interval"`
+
+	deviceError
+}
+
+// DeviceToken is the response when polling for the access token, Error
+// is set until the user authorizes the device.
+type DeviceToken struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	Scope       string `json:"scope"`
+	Interval    int    `json:"interval"`
+
+	deviceError
+}
+
+// deviceError is the error response defined by RFC 6749 section 5.2
+type deviceError struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+	ErrorURI         string `json:"error_uri"`
+}
+
+func (e deviceError) Err() error {
+	msg := "GitHub returned: " + e.Error
+	if len(e.ErrorDescription) > 0 {
+		msg += ": " + e.ErrorDescription
+	}
+	if len(e.ErrorURI) > 0 {
+		msg += " (" + e.ErrorURI + ")"
+	}
+	return errors.New(msg)
 }
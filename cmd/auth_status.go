@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// requiredScopes are needed by the actuated API to look up the user's
+// organisations and email address.
+var requiredScopes = []string{"read:org", "user:email"}
+
+func makeAuthStatus() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the GitHub login, scopes, source and expiry of the token",
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.RunE = runAuthStatusE
+
+	return cmd
+}
+
+func runAuthStatusE(cmd *cobra.Command, args []string) error {
+	pat, source, err := getPatWithSource(cmd)
+	if err != nil {
+		return err
+	}
+
+	if len(pat) == 0 {
+		return fmt.Errorf("no token found from: %s, run \"actuated-cli auth\"", source)
+	}
+
+	if patFile, ok := strings.CutPrefix(source, "file "); ok && isEncryptedPatFile(patFile) {
+		source += " (encrypted)"
+	}
+
+	fmt.Printf("Token source: %s\n", source)
+
+	client, err := newGitHubClient(cmd, pat)
+	if err != nil {
+		return err
+	}
+
+	user, res, err := client.Users.Get(cmd.Context(), "")
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Logged in to %s as: %s\n", githubHost(cmd), user.GetLogin())
+
+	scopes := strings.TrimSpace(res.Header.Get("X-OAuth-Scopes"))
+	if len(scopes) > 0 {
+		fmt.Printf("Scopes: %s\n", scopes)
+	} else {
+		fmt.Printf("Scopes: none (fine-grained tokens do not list scopes)\n")
+	}
+
+	if expiry := res.Header.Get("GitHub-Authentication-Token-Expiration"); len(expiry) > 0 {
+		fmt.Printf("Expires: %s\n", expiry)
+	} else {
+		fmt.Printf("Expires: never\n")
+	}
+
+	if len(scopes) > 0 {
+		if missing := missingScopes(scopes); len(missing) > 0 {
+			fmt.Printf("Warning: the token is missing the scopes: %s, run \"actuated-cli auth\" to obtain a new token\n",
+				strings.Join(missing, ", "))
+		}
+	}
+
+	return nil
+}
+
+// missingScopes returns the requiredScopes which are not present in the
+// comma-separated scopes reported by GitHub.
+func missingScopes(scopes string) []string {
+	granted := map[string]bool{}
+	for _, s := range strings.Split(scopes, ",") {
+		granted[strings.TrimSpace(s)] = true
+	}
+
+	var missing []string
+	for _, s := range requiredScopes {
+		if granted[s] {
+			continue
+		}
+
+		// Broader scopes which include the required ones
+		if s == "read:org" && (granted["write:org"] || granted["admin:org"]) {
+			continue
+		}
+		if s == "user:email" && granted["user"] {
+			continue
+		}
+
+		missing = append(missing, s)
+	}
+
+	return missing
+}
+
+func makeAuthToken() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "token",
+		Short: "Print the token for use with other tools",
+		Example: `  # Use the token with curl
+  curl -H "Authorization: Bearer $(actuated-cli auth token)" $ACTUATED_URL/api/v1/job-queue
+`,
+		Args: cobra.NoArgs,
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		pat, source, err := getPatWithSource(cmd)
+		if err != nil {
+			return err
+		}
+
+		if len(pat) == 0 {
+			return fmt.Errorf("no token found from: %s, run \"actuated-cli auth\"", source)
+		}
+
+		fmt.Println(pat)
+		return nil
+	}
+
+	return cmd
+}
+
+func makeAuthLogout() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "logout",
+		Short: "Delete the saved token",
+		Long: `Delete the saved token file.
+
+GitHub only allows the owner of the OAuth app to revoke a token through its
+API, so to revoke the token, visit the link printed by this command.`,
+		Args: cobra.NoArgs,
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		patFile, err := patFilePath(cmd)
+		if err != nil {
+			return err
+		}
+
+		if err := os.Remove(patFile); errors.Is(err, fs.ErrNotExist) {
+			fmt.Printf("No token found at: %s\n", patFile)
+		} else if err != nil {
+			return err
+		} else {
+			fmt.Printf("Deleted token: %s\n", patFile)
+		}
+
+		if _, source, err := getPatWithSource(cmd); err == nil && !strings.HasPrefix(source, "file ") {
+			fmt.Printf("A token is still available from: %s\n", source)
+		}
+
+		fmt.Printf("To revoke the token, visit: https://%s/settings/connections/applications/%s\n", githubHost(cmd), oauthClientID(cmd))
+
+		return nil
+	}
+
+	return cmd
+}
@@ -0,0 +1,407 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultConfigPath = "$HOME/.actuated/config.yaml"
+
+// cliConfig is the config file holding named contexts, each context is an
+// actuated installation and the defaults to use with it.
+type cliConfig struct {
+	CurrentContext string          `yaml:"current-context,omitempty"`
+	Contexts       []configContext `yaml:"contexts,omitempty"`
+}
+
+type configContext struct {
+	Name      string `yaml:"name"`
+	URL       string `yaml:"url,omitempty"`
+	TokenFile string `yaml:"token-file,omitempty"`
+	Owner     string `yaml:"owner,omitempty"`
+	Staff     bool   `yaml:"staff,omitempty"`
+	Output    string `yaml:"output,omitempty"`
+
+	// GitHubHost is the host of a GitHub Enterprise Server
+	GitHubHost string `yaml:"github-host,omitempty"`
+
+	// GitHubClientID is the OAuth app for "auth" on GitHub Enterprise Server
+	GitHubClientID string `yaml:"github-client-id,omitempty"`
+
+	// Exec runs a credential plugin to obtain the token
+	Exec *execCredentialConfig `yaml:"exec,omitempty"`
+}
+
+// activeContext is the context selected by --context or current-context,
+// it is nil when there is no config file or no context is selected.
+var activeContext *configContext
+
+func (c *cliConfig) context(name string) *configContext {
+	for i := range c.Contexts {
+		if c.Contexts[i].Name == name {
+			return &c.Contexts[i]
+		}
+	}
+	return nil
+}
+
+// configPath returns the path of the config file, which can be overridden
+// with ACTUATED_CONFIG.
+func configPath() string {
+	if v, ok := os.LookupEnv("ACTUATED_CONFIG"); ok && v != "" {
+		return os.ExpandEnv(v)
+	}
+	return os.ExpandEnv(defaultConfigPath)
+}
+
+// loadConfig reads the config file, a missing file gives an empty config.
+func loadConfig() (*cliConfig, error) {
+	config := &cliConfig{}
+
+	data, err := os.ReadFile(configPath())
+	if errors.Is(err, fs.ErrNotExist) {
+		return config, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", configPath(), err)
+	}
+
+	return config, nil
+}
+
+func saveConfig(config *cliConfig) error {
+	buf := &bytes.Buffer{}
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(config); err != nil {
+		return err
+	}
+
+	p := configPath()
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(p, buf.Bytes(), 0600)
+}
+
+// loadActiveContext sets activeContext from the --context flag, or from
+// current-context in the config file.
+func loadActiveContext(cmd *cobra.Command) error {
+	config, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	name, err := cmd.Root().PersistentFlags().GetString("context")
+	if err != nil {
+		return err
+	}
+
+	if len(name) == 0 {
+		name = config.CurrentContext
+	}
+
+	if len(name) == 0 {
+		activeContext = nil
+		return nil
+	}
+
+	activeContext = config.context(name)
+	if activeContext == nil {
+		return fmt.Errorf("context %q not found in %s, run \"actuated-cli config get-contexts\"", name, configPath())
+	}
+
+	return nil
+}
+
+// actuatedURL returns ACTUATED_URL from the environment, or the URL of the
+// active context.
+func actuatedURL() string {
+	if v, ok := os.LookupEnv("ACTUATED_URL"); ok && v != "" {
+		return v
+	}
+
+	if activeContext != nil {
+		return activeContext.URL
+	}
+
+	return ""
+}
+
+// getOwner returns owner, or the default owner of the active context when
+// owner is empty.
+func getOwner(owner string) string {
+	if len(owner) == 0 && activeContext != nil {
+		return activeContext.Owner
+	}
+	return owner
+}
+
+// getStaff returns the --staff flag, or the staff setting of the active
+// context when the flag was not given.
+func getStaff(cmd *cobra.Command) (bool, error) {
+	if !cmd.Flags().Changed("staff") && activeContext != nil {
+		return activeContext.Staff, nil
+	}
+
+	return cmd.Flags().GetBool("staff")
+}
+
+func makeConfig() *cobra.Command {
+	config := &cobra.Command{
+		Use:   "config",
+		Short: "Manage contexts for multiple actuated installations",
+		Long: `Manage named contexts in $HOME/.actuated/config.yaml, or the file given by
+ACTUATED_CONFIG.
+
+Each context holds the URL of an actuated installation, along with the token
+file, default owner, staff flag and output format to use with it. Select a
+context with "config use-context", or for a single command with --context.
+
+The ACTUATED_URL environment variable and flags such as --token, --owner and
+--staff take precedence over the values in the context.`,
+		Example: `  # Add a context and make it the current context
+  actuated-cli config set contexts.acme.url https://example.com
+  actuated-cli config set contexts.acme.owner acme
+  actuated-cli config use-context acme
+
+  # Use a different context for a single command
+  actuated-cli jobs --context staging
+`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	// The config commands work without ACTUATED_URL or a valid context
+	config.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return nil
+	}
+
+	config.AddCommand(makeConfigGetContexts())
+	config.AddCommand(makeConfigCurrentContext())
+	config.AddCommand(makeConfigUseContext())
+	config.AddCommand(makeConfigSet())
+
+	return config
+}
+
+func makeConfigGetContexts() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "get-contexts",
+		Short: "List the contexts in the config file",
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		config, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		table := tablewriter.NewWriter(cmd.OutOrStdout())
+		table.SetHeader([]string{"Current", "Name", "URL", "Owner", "Staff", "Output"})
+		table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+		table.SetCenterSeparator("|")
+		table.SetAutoWrapText(false)
+
+		for _, c := range config.Contexts {
+			current := ""
+			if c.Name == config.CurrentContext {
+				current = "*"
+			}
+			table.Append([]string{current, c.Name, c.URL, c.Owner, strconv.FormatBool(c.Staff), c.Output})
+		}
+
+		table.Render()
+		return nil
+	}
+
+	return cmd
+}
+
+func makeConfigCurrentContext() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "current-context",
+		Short: "Print the current context",
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		config, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		if len(config.CurrentContext) == 0 {
+			return fmt.Errorf("current-context is not set")
+		}
+
+		fmt.Println(config.CurrentContext)
+		return nil
+	}
+
+	return cmd
+}
+
+func makeConfigUseContext() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "use-context NAME",
+		Short:   "Set the current context",
+		Example: `  actuated-cli config use-context acme`,
+		Args:    cobra.ExactArgs(1),
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		config, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		name := args[0]
+		if config.context(name) == nil {
+			return fmt.Errorf("context %q not found in %s", name, configPath())
+		}
+
+		config.CurrentContext = name
+		if err := saveConfig(config); err != nil {
+			return err
+		}
+
+		fmt.Printf("Switched to context %q\n", name)
+		return nil
+	}
+
+	return cmd
+}
+
+func makeConfigSet() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set KEY VALUE",
+		Short: "Set a value in the config file",
+		Long: `Set a value in the config file, the context is created if it does not exist.
+
+Keys:
+  current-context
+  contexts.NAME.url
+  contexts.NAME.token-file
+  contexts.NAME.owner
+  contexts.NAME.staff
+  contexts.NAME.output     (table, wide, json, yaml, csv, ndjson, go-template=, jsonpath=)
+  contexts.NAME.github-host
+  contexts.NAME.github-client-id
+  contexts.NAME.exec.command
+  contexts.NAME.exec.args  (comma-separated)
+
+The exec command is a credential plugin, which prints a token as JSON to
+stdout, i.e. {"token": "...", "expiresAt": "2024-01-01T00:00:00Z"}. The
+token is kept in memory until it expires, and is never written to disk.
+Additional environment variables for the plugin can be given under
+exec.env by editing the config file.`,
+		Example: `  actuated-cli config set contexts.acme.url https://example.com
+  actuated-cli config set contexts.acme.token-file '$HOME/.actuated/acme-PAT'
+  actuated-cli config set contexts.acme.output json
+  actuated-cli config set current-context acme
+
+  # Obtain the token from a credential plugin
+  actuated-cli config set contexts.acme.exec.command vault-github-token
+  actuated-cli config set contexts.acme.exec.args read,--format=json
+`,
+		Args: cobra.ExactArgs(2),
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		config, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		if err := setConfigValue(config, args[0], args[1]); err != nil {
+			return err
+		}
+
+		return saveConfig(config)
+	}
+
+	return cmd
+}
+
+func setConfigValue(config *cliConfig, key, value string) error {
+	if key == "current-context" {
+		if config.context(value) == nil {
+			return fmt.Errorf("context %q not found in %s", value, configPath())
+		}
+		config.CurrentContext = value
+		return nil
+	}
+
+	parts := strings.SplitN(key, ".", 3)
+	if len(parts) != 3 || parts[0] != "contexts" || len(parts[1]) == 0 {
+		return fmt.Errorf("unknown key: %s, see \"actuated-cli config set --help\"", key)
+	}
+
+	name, field := parts[1], parts[2]
+
+	c := config.context(name)
+	if c == nil {
+		config.Contexts = append(config.Contexts, configContext{Name: name})
+		c = &config.Contexts[len(config.Contexts)-1]
+	}
+
+	switch field {
+	case "url":
+		c.URL = value
+	case "token-file":
+		c.TokenFile = value
+	case "owner":
+		c.Owner = value
+	case "staff":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("staff must be true or false")
+		}
+		c.Staff = v
+	case "github-host":
+		c.GitHubHost = value
+	case "github-client-id":
+		c.GitHubClientID = value
+	case "exec.command":
+		if c.Exec == nil {
+			c.Exec = &execCredentialConfig{}
+		}
+		c.Exec.Command = value
+		if len(value) == 0 {
+			c.Exec = nil
+		}
+	case "exec.args":
+		if c.Exec == nil {
+			c.Exec = &execCredentialConfig{}
+		}
+		c.Exec.Args = nil
+		if len(value) > 0 {
+			c.Exec.Args = strings.Split(value, ",")
+		}
+	case "output":
+		if _, err := parseOutputFormat(value); err != nil {
+			return err
+		}
+		c.Output = value
+	default:
+		return fmt.Errorf("unknown key: %s, see \"actuated-cli config set --help\"", key)
+	}
+
+	return nil
+}
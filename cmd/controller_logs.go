@@ -2,11 +2,8 @@ package cmd
 
 import (
 	"fmt"
-	"net/http"
-	"os"
 	"time"
 
-	"github.com/self-actuated/actuated-cli/pkg"
 	"github.com/spf13/cobra"
 )
 
@@ -47,16 +44,15 @@ func runControllerLogsE(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("pat is required")
 	}
 
-	c := pkg.NewClient(http.DefaultClient, os.Getenv("ACTUATED_URL"))
-
-	res, status, err := c.GetControllerLogs(pat, outputFormat, age)
-
+	c, err := newClient(cmd)
 	if err != nil {
 		return err
 	}
 
-	if status != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d, message: %s", status, res)
+	res, _, err := c.GetControllerLogs(cmd.Context(), pat, outputFormat, age)
+
+	if err != nil {
+		return err
 	}
 
 	fmt.Println(res)
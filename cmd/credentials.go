@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// helperTimeout limits how long "gh auth token" and "git credential fill"
+// may take
+const helperTimeout = time.Second * 5
+
+// getPat returns the token for the actuated API and GitHub, see
+// getPatWithSource for the order in which sources are tried.
+func getPat(cmd *cobra.Command) (string, error) {
+	pat, _, err := getPatWithSource(cmd)
+	return pat, err
+}
+
+// getPatWithSource returns the token along with a description of where it
+// was found, for "auth status". The first source with a token wins:
+//
+//  1. --token-value or --token flags
+//  2. ACTUATED_TOKEN environment variable
+//  3. The credential plugin from the exec setting of the active context
+//  4. GH_TOKEN or GITHUB_TOKEN environment variables, GITHUB_TOKEN is
+//     skipped within GitHub Actions, where it is an installation token
+//     which the actuated API rejects, so set GH_TOKEN there instead
+//  5. "gh auth token" from the GitHub CLI
+//  6. The git credential helper for the GitHub host
+//  7. The token file from the active context or $HOME/.actuated/PAT
+//
+// gh and git are only run when none of the sources before them has a
+// token, and at most once per command.
+func getPatWithSource(cmd *cobra.Command) (string, string, error) {
+	if cmd.Flags().Changed("token-value") {
+		pat, err := cmd.Flags().GetString("token-value")
+		return pat, "--token-value flag", err
+	}
+
+	if cmd.Flags().Changed("token") {
+		patFile, err := patFilePath(cmd)
+		if err != nil {
+			return "", "", err
+		}
+
+		pat, err := readPatFile(patFile)
+		return pat, "file " + patFile, err
+	}
+
+	if v := strings.TrimSpace(os.Getenv("ACTUATED_TOKEN")); len(v) > 0 {
+		return v, "ACTUATED_TOKEN environment variable", nil
+	}
+
+	if activeContext != nil && activeContext.Exec != nil && len(activeContext.Exec.Command) > 0 {
+		pat, err := execToken(cmd.Context(), *activeContext.Exec)
+		return pat, "credential plugin " + activeContext.Exec.String(), err
+	}
+
+	envs := []string{"GH_TOKEN", "GITHUB_TOKEN"}
+	if os.Getenv("GITHUB_ACTIONS") == "true" {
+		envs = []string{"GH_TOKEN"}
+	}
+
+	for _, env := range envs {
+		if v := strings.TrimSpace(os.Getenv(env)); len(v) > 0 {
+			return v, env + " environment variable", nil
+		}
+	}
+
+	host := githubHost(cmd)
+
+	if pat := helperToken("gh", host, ghAuthToken); len(pat) > 0 {
+		return pat, "gh auth token", nil
+	}
+
+	if pat := helperToken("git", host, gitCredentialToken); len(pat) > 0 {
+		return pat, "git credential helper", nil
+	}
+
+	patFile, err := patFilePath(cmd)
+	if err != nil {
+		return "", "", err
+	}
+
+	pat, err := readPatFile(patFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", "", fmt.Errorf("no token found, run \"actuated-cli auth\", or set ACTUATED_TOKEN: %w", err)
+	}
+
+	return pat, "file " + patFile, err
+}
+
+// helperTokens caches the tokens from gh and git by tool and host, so that
+// they are run at most once per command.
+var helperTokens = map[string]string{}
+
+func helperToken(tool, host string, fetch func(ctx context.Context, host string) string) string {
+	key := tool + "/" + host
+	if pat, ok := helperTokens[key]; ok {
+		return pat
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), helperTimeout)
+	defer cancel()
+
+	pat := fetch(ctx, host)
+	helperTokens[key] = pat
+	return pat
+}
+
+// ghAuthToken returns the token from the GitHub CLI for the host, or an
+// empty string when gh is not installed or not logged in.
+func ghAuthToken(ctx context.Context, host string) string {
+	if _, err := exec.LookPath("gh"); err != nil {
+		return ""
+	}
+
+	out, err := exec.CommandContext(ctx, "gh", "auth", "token", "--hostname", host).Output()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+// gitCredentialToken returns the password stored by the git credential
+// helper for the host, or an empty string when there is none. git is not
+// allowed to prompt for one.
+func gitCredentialToken(ctx context.Context, host string) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		return ""
+	}
+
+	c := exec.CommandContext(ctx, "git", "credential", "fill")
+	c.Stdin = strings.NewReader("protocol=https\nhost=" + host + "\n\n")
+	c.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "SSH_ASKPASS=")
+
+	out, err := c.Output()
+	if err != nil {
+		return ""
+	}
+
+	return parseGitCredential(string(out))
+}
+
+// parseGitCredential returns the password from the output of
+// "git credential fill", which is given as key=value lines.
+func parseGitCredential(out string) string {
+	for _, line := range strings.Split(out, "\n") {
+		if v, ok := strings.CutPrefix(line, "password="); ok {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+// patFilePath returns the file given by --token, or the token file of the
+// active context when the flag was not given.
+func patFilePath(cmd *cobra.Command) (string, error) {
+	v, err := cmd.Flags().GetString("token")
+	if err != nil {
+		return "", err
+	}
+
+	if !cmd.Flags().Changed("token") && activeContext != nil && len(activeContext.TokenFile) > 0 {
+		v = activeContext.TokenFile
+	}
+
+	if len(v) == 0 {
+		return "", fmt.Errorf("give --token or --token-value")
+	}
+
+	return os.ExpandEnv(v), nil
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// execTimeout limits how long a credential plugin may take, to allow
+	// for plugins which prompt for a password or a biometric check
+	execTimeout = time.Minute * 2
+
+	// execExpirySkew refreshes a cached token shortly before it expires
+	execExpirySkew = time.Second * 30
+)
+
+// execCredentialConfig runs a command to obtain a token, such as a wrapper
+// for Vault or 1Password, so that the token is never written to disk.
+type execCredentialConfig struct {
+	Command string            `yaml:"command"`
+	Args    []string          `yaml:"args,omitempty"`
+	Env     map[string]string `yaml:"env,omitempty"`
+}
+
+// execCredential is printed to stdout by the command. The kubectl
+// ExecCredential format with a "status" object is also accepted.
+type execCredential struct {
+	Token     string     `json:"token"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+
+	Status *struct {
+		Token               string     `json:"token"`
+		ExpirationTimestamp *time.Time `json:"expirationTimestamp,omitempty"`
+	} `json:"status,omitempty"`
+}
+
+type cachedCredential struct {
+	token     string
+	expiresAt time.Time
+}
+
+var (
+	execCacheLock sync.Mutex
+	execCache     = map[string]cachedCredential{}
+)
+
+func (c execCredentialConfig) String() string {
+	return strings.TrimSpace(c.Command + " " + strings.Join(c.Args, " "))
+}
+
+// execToken returns the token from the credential plugin, the result is
+// cached in memory until it expires, or for the life of the process when
+// no expiry is given.
+func execToken(ctx context.Context, config execCredentialConfig) (string, error) {
+	key := config.String()
+
+	execCacheLock.Lock()
+	defer execCacheLock.Unlock()
+
+	if cached, ok := execCache[key]; ok {
+		if cached.expiresAt.IsZero() || time.Now().Add(execExpirySkew).Before(cached.expiresAt) {
+			return cached.token, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, execTimeout)
+	defer cancel()
+
+	c := exec.CommandContext(ctx, os.ExpandEnv(config.Command), config.Args...)
+	c.Env = os.Environ()
+	for k, v := range config.Env {
+		c.Env = append(c.Env, k+"="+v)
+	}
+
+	// The plugin may prompt the user for a password
+	stdout := &bytes.Buffer{}
+	c.Stdin = os.Stdin
+	c.Stdout = stdout
+	c.Stderr = os.Stderr
+
+	if err := c.Run(); err != nil {
+		return "", fmt.Errorf("credential plugin %q failed: %w", key, err)
+	}
+
+	var cred execCredential
+	if err := json.Unmarshal(stdout.Bytes(), &cred); err != nil {
+		return "", fmt.Errorf("credential plugin %q returned invalid JSON: %w", key, err)
+	}
+
+	token, expiresAt := cred.Token, cred.ExpiresAt
+	if cred.Status != nil && len(token) == 0 {
+		token, expiresAt = cred.Status.Token, cred.Status.ExpirationTimestamp
+	}
+
+	if len(token) == 0 {
+		return "", fmt.Errorf("credential plugin %q returned no token", key)
+	}
+
+	cached := cachedCredential{token: token}
+	if expiresAt != nil {
+		if !time.Now().Before(*expiresAt) {
+			return "", fmt.Errorf("credential plugin %q returned a token which expired at %s", key, expiresAt.Format(time.RFC3339))
+		}
+		cached.expiresAt = *expiresAt
+	}
+	execCache[key] = cached
+
+	return token, nil
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestGetPatWithSourceOrder(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("gh and git are faked with shell scripts")
+	}
+
+	tests := []struct {
+		name       string
+		tokenValue string
+		env        map[string]string
+		gh         bool
+		git        bool
+		file       bool
+		want       string
+		wantSource string
+	}{
+		{
+			name:       "--token-value before everything",
+			tokenValue: "flag",
+			env:        map[string]string{"ACTUATED_TOKEN": "actuated", "GH_TOKEN": "gh-env"},
+			gh:         true, git: true, file: true,
+			want: "flag", wantSource: "--token-value flag",
+		},
+		{
+			name: "ACTUATED_TOKEN before GH_TOKEN",
+			env:  map[string]string{"ACTUATED_TOKEN": "actuated", "GH_TOKEN": "gh-env"},
+			gh:   true, git: true, file: true,
+			want: "actuated", wantSource: "ACTUATED_TOKEN environment variable",
+		},
+		{
+			name: "GH_TOKEN before GITHUB_TOKEN",
+			env:  map[string]string{"GH_TOKEN": "gh-env", "GITHUB_TOKEN": "github-env"},
+			gh:   true, git: true, file: true,
+			want: "gh-env", wantSource: "GH_TOKEN environment variable",
+		},
+		{
+			name: "GITHUB_TOKEN before gh",
+			env:  map[string]string{"GITHUB_TOKEN": "github-env"},
+			gh:   true, git: true, file: true,
+			want: "github-env", wantSource: "GITHUB_TOKEN environment variable",
+		},
+		{
+			name: "GITHUB_TOKEN is skipped in GitHub Actions",
+			env:  map[string]string{"GITHUB_TOKEN": "github-env", "GITHUB_ACTIONS": "true"},
+			gh:   true, git: true, file: true,
+			want: "gh-cli", wantSource: "gh auth token",
+		},
+		{
+			name: "GH_TOKEN is used in GitHub Actions",
+			env:  map[string]string{"GH_TOKEN": "gh-env", "GITHUB_ACTIONS": "true"},
+			gh:   true, git: true, file: true,
+			want: "gh-env", wantSource: "GH_TOKEN environment variable",
+		},
+		{
+			name: "gh before the git credential helper",
+			gh:   true, git: true, file: true,
+			want: "gh-cli", wantSource: "gh auth token",
+		},
+		{
+			name: "git credential helper before the file",
+			git:  true, file: true,
+			want: "git-helper", wantSource: "git credential helper",
+		},
+		{
+			name: "file last",
+			file: true,
+			want: "file", wantSource: "file",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			helperTokens = map[string]string{}
+
+			for _, env := range []string{"ACTUATED_TOKEN", "GH_TOKEN", "GITHUB_TOKEN", "GITHUB_ACTIONS", "ACTUATED_GITHUB_HOST"} {
+				t.Setenv(env, tc.env[env])
+			}
+			t.Setenv("PATH", dir)
+
+			if tc.gh {
+				writeScript(t, dir, "gh", "echo gh-cli")
+			}
+			if tc.git {
+				writeScript(t, dir, "git", `cat >/dev/null; printf 'protocol=https\nhost=github.com\nusername=x\npassword=git-helper\n'`)
+			}
+
+			patFile := filepath.Join(dir, "PAT")
+			if tc.file {
+				if err := os.WriteFile(patFile, []byte("file\n"), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cmd := &cobra.Command{}
+			cmd.Flags().String("token-value", "", "")
+			cmd.Flags().String("token", patFile, "")
+			if len(tc.tokenValue) > 0 {
+				if err := cmd.Flags().Set("token-value", tc.tokenValue); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			pat, source, err := getPatWithSource(cmd)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tc.wantSource == "file" {
+				tc.wantSource = "file " + patFile
+			}
+
+			if pat != tc.want || source != tc.wantSource {
+				t.Errorf("want %q from %q, got %q from %q", tc.want, tc.wantSource, pat, source)
+			}
+		})
+	}
+}
+
+func TestGetPatWithSourceNoToken(t *testing.T) {
+	dir := t.TempDir()
+	helperTokens = map[string]string{}
+
+	for _, env := range []string{"ACTUATED_TOKEN", "GH_TOKEN", "GITHUB_TOKEN", "GITHUB_ACTIONS", "ACTUATED_GITHUB_HOST"} {
+		t.Setenv(env, "")
+	}
+	t.Setenv("PATH", dir)
+
+	cmd := &cobra.Command{}
+	cmd.Flags().String("token-value", "", "")
+	cmd.Flags().String("token", filepath.Join(dir, "PAT"), "")
+
+	if _, source, err := getPatWithSource(cmd); err == nil {
+		t.Errorf("want an error, got a token from %q", source)
+	}
+}
+
+func TestParseGitCredential(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+		want string
+	}{
+		{"password", "protocol=https\nhost=github.com\nusername=x\npassword=abc\n", "abc"},
+		{"no password", "protocol=https\nhost=github.com\n", ""},
+		{"empty", "", ""},
+		{"CRLF", "username=x\r\npassword=abc\r\n", "abc"},
+	}
+
+	for _, tc := range tests {
+		if got := parseGitCredential(tc.out); got != tc.want {
+			t.Errorf("%s: want %q, got %q", tc.name, tc.want, got)
+		}
+	}
+}
+
+func writeScript(t *testing.T, dir, name, body string) {
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+body+"\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,282 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/self-actuated/actuated-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+func makeDescribe() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "describe URL",
+		Short: "Show everything about a job from its GitHub URL",
+		Long: `Show the status, labels, runner, server and timings of a job from its URL
+on GitHub, along with the commands to fetch the logs and metering for its
+VM, and to connect to its SSH session if one exists.
+
+The job is found in the build queue, jobs which have finished are looked up
+with the GitHub API instead, then the server is not known, so give it with
+--host to fetch the logs or metering.`,
+		Example: `  # Describe a job from a link to its page on GitHub
+  actuated-cli describe https://github.com/acme/api/actions/runs/1234/job/5678
+
+  # Fetch the logs from the VM which ran the job
+  actuated-cli describe https://github.com/acme/api/actions/runs/1234/job/5678 --logs
+
+  # Fetch the metering snapshot of a job which has finished
+  actuated-cli describe https://github.com/acme/api/runs/5678 --metering --host HOST
+`,
+		Args: cobra.ExactArgs(1),
+	}
+
+	cmd.RunE = runDescribeE
+
+	cmd.Flags().Bool("logs", false, "Fetch the logs from the VM which ran the job")
+	cmd.Flags().Bool("metering", false, "Fetch the metering snapshot from the VM which ran the job")
+	cmd.Flags().String("host", "", "Server which ran the job, for jobs which are no longer in the build queue")
+	addOutputFlags(cmd)
+
+	cmd.MarkFlagsMutuallyExclusive("logs", "metering")
+
+	return cmd
+}
+
+// jobDetails is a job from the build queue, or from GitHub once it has
+// finished.
+type jobDetails struct {
+	pkg.JobStatus
+
+	// InQueue is false when the job was only found on GitHub
+	InQueue bool `json:"inQueue"`
+
+	SSHSession *sshSession `json:"sshSession,omitempty"`
+}
+
+func runDescribeE(cmd *cobra.Command, args []string) error {
+	ref, err := parseJobURL(strings.TrimSpace(args[0]))
+	if err != nil {
+		return err
+	}
+
+	pat, err := getPat(cmd)
+	if err != nil {
+		return err
+	}
+
+	staff, err := getStaff(cmd)
+	if err != nil {
+		return err
+	}
+
+	output, err := getOutputFormat(cmd)
+	if err != nil {
+		return err
+	}
+
+	logs, err := cmd.Flags().GetBool("logs")
+	if err != nil {
+		return err
+	}
+
+	metering, err := cmd.Flags().GetBool("metering")
+	if err != nil {
+		return err
+	}
+
+	host, err := cmd.Flags().GetString("host")
+	if err != nil {
+		return err
+	}
+
+	if len(pat) == 0 {
+		return fmt.Errorf("pat is required")
+	}
+
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	details, err := findJob(cmd, c, pat, ref, staff)
+	if err != nil {
+		return err
+	}
+
+	if len(host) > 0 {
+		details.AgentName = host
+	}
+
+	ctx := cmd.Context()
+	w := os.Stdout
+
+	if logs || metering {
+		if len(details.RunnerName) == 0 {
+			return fmt.Errorf("the job has not been assigned a runner")
+		}
+		if len(details.AgentName) == 0 {
+			return fmt.Errorf("the server which ran the job is not known, give it with --host")
+		}
+
+		var res string
+		if logs {
+			res, _, err = c.GetLogs(ctx, pat, ref.Owner, details.AgentName, details.RunnerName, time.Minute*15, staff)
+		} else {
+			res, _, err = c.GetMetering(ctx, pat, ref.Owner, details.AgentName, details.RunnerName, staff)
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(w, res)
+		return nil
+	}
+
+	// A session can only exist whilst the job is running
+	if details.Status == "in_progress" && len(details.RunnerName) > 0 {
+		sessions, err := listSSHSessions(cmd, pat)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: unable to list SSH sessions: %s\n", err)
+		}
+		for _, session := range sessions {
+			if session.Hostname == details.RunnerName {
+				details.SSHSession = &session
+				break
+			}
+		}
+	}
+
+	return printOutput(w, output, details,
+		func(w io.Writer, wide bool) error {
+			printJobDetails(w, details)
+			return nil
+		},
+		func() ([]string, [][]string) {
+			return jobRecords([]pkg.JobStatus{details.JobStatus})
+		})
+}
+
+// findJob finds the job in the build queue, or looks it up on GitHub when
+// it is no longer queued or running.
+func findJob(cmd *cobra.Command, c *pkg.Client, pat string, ref jobRef, staff bool) (jobDetails, error) {
+	statuses, err := c.ListJobs(cmd.Context(), pat, ref.Owner, staff)
+	if err != nil {
+		return jobDetails{}, err
+	}
+
+	for _, status := range statuses {
+		if status.JobID == ref.JobID {
+			status.URL = jobURL(cmd, status)
+			return jobDetails{JobStatus: status, InQueue: true}, nil
+		}
+	}
+
+	ghClient, err := newGitHubClient(cmd, pat)
+	if err != nil {
+		return jobDetails{}, err
+	}
+
+	job, _, err := ghClient.Actions.GetWorkflowJobByID(cmd.Context(), ref.Owner, ref.Repo, ref.JobID)
+	if err != nil {
+		return jobDetails{}, fmt.Errorf("job %d is not in the build queue, and could not be found on GitHub: %w", ref.JobID, err)
+	}
+
+	status := pkg.JobStatus{
+		JobID:        ref.JobID,
+		Owner:        ref.Owner,
+		Repo:         ref.Repo,
+		WorkflowName: job.GetWorkflowName(),
+		JobName:      job.GetName(),
+		RunnerName:   job.GetRunnerName(),
+		Status:       job.GetStatus(),
+		Conclusion:   job.GetConclusion(),
+		Labels:       job.Labels,
+		URL:          job.GetHTMLURL(),
+		StartedAt:    job.StartedAt.GetTime(),
+		CompletedAt:  job.CompletedAt.GetTime(),
+		QueuedAt:     job.CreatedAt.GetTime(),
+	}
+
+	return jobDetails{JobStatus: status}, nil
+}
+
+func printJobDetails(w io.Writer, d jobDetails) {
+	now := time.Now()
+	status := d.Status
+	if len(d.Conclusion) > 0 {
+		status += " (" + d.Conclusion + ")"
+	}
+	if !d.InQueue {
+		status += ", not in the build queue"
+	}
+
+	job := d.JobName
+	if len(d.WorkflowName) > 0 {
+		job = d.WorkflowName + "/" + job
+	}
+
+	fmt.Fprintf(w, "Job: %s/%s %s\n", d.Owner, d.Repo, job)
+	fmt.Fprintf(w, "Status: %s\n", status)
+	if len(d.Actor) > 0 {
+		fmt.Fprintf(w, "Actor: %s\n", d.Actor)
+	}
+	fmt.Fprintf(w, "Labels: %s\n", strings.Join(d.Labels, ","))
+	fmt.Fprintf(w, "Runner: %s\n", d.RunnerName)
+	fmt.Fprintf(w, "Server: %s\n", d.AgentName)
+
+	if queuedAt := jobQueuedAt(d.JobStatus); queuedAt != nil {
+		fmt.Fprintf(w, "Queued: %s\n", queuedAt.Format(time.RFC3339))
+	}
+	if d.StartedAt != nil {
+		fmt.Fprintf(w, "Started: %s\n", d.StartedAt.Format(time.RFC3339))
+	}
+	if d.CompletedAt != nil && !d.CompletedAt.IsZero() {
+		fmt.Fprintf(w, "Completed: %s\n", d.CompletedAt.Format(time.RFC3339))
+	}
+	if wait, ok := jobWait(d.JobStatus, now); ok {
+		fmt.Fprintf(w, "Wait: %s\n", wait.Round(time.Second))
+	}
+	if d.InQueue {
+		if elapsed := formatElapsed(d.JobStatus, now); len(elapsed) > 0 {
+			fmt.Fprintf(w, "Elapsed: %s\n", elapsed)
+		}
+	} else if d.StartedAt != nil && d.CompletedAt != nil && !d.CompletedAt.IsZero() {
+		fmt.Fprintf(w, "Duration: %s\n", d.CompletedAt.Sub(*d.StartedAt).Round(time.Second))
+	}
+	if d.AverageRuntime > 0 {
+		fmt.Fprintf(w, "Average runtime: %s\n", d.AverageRuntime.Round(time.Second))
+	}
+	fmt.Fprintf(w, "URL: %s\n", d.URL)
+
+	if d.SSHSession != nil {
+		fmt.Fprintf(w, "SSH session: connected %s ago\n", sshSessionAge(*d.SSHSession, now))
+	}
+
+	if len(d.RunnerName) == 0 {
+		return
+	}
+
+	host := d.AgentName
+	if len(host) == 0 {
+		host = "HOST"
+	}
+
+	fmt.Fprintf(w, "\nFollow-up commands:\n")
+	fmt.Fprintf(w, "  # VM logs\n  actuated-cli logs --owner %s --id %s %s\n", d.Owner, d.RunnerName, host)
+	fmt.Fprintf(w, "  # Metering\n  actuated-cli metering --owner %s --id %s %s\n", d.Owner, d.RunnerName, host)
+	if d.SSHSession != nil {
+		fmt.Fprintf(w, "  # SSH session\n  actuated-cli ssh connect %s\n", d.RunnerName)
+	}
+}
+
+func sshSessionAge(session sshSession, now time.Time) time.Duration {
+	connectedAt, err := time.Parse(time.RFC3339, session.ConnectedAt)
+	if err != nil {
+		return 0
+	}
+	return now.Sub(connectedAt).Round(time.Second)
+}
@@ -2,11 +2,8 @@ package cmd
 
 import (
 	"fmt"
-	"net/http"
-	"os"
 	"strings"
 
-	"github.com/self-actuated/actuated-cli/pkg"
 	"github.com/spf13/cobra"
 )
 
@@ -41,7 +38,7 @@ func runDisableE(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	staff, err := cmd.Flags().GetBool("staff")
+	staff, err := getStaff(cmd)
 	if err != nil {
 		return err
 	}
@@ -50,6 +47,7 @@ func runDisableE(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	owner = getOwner(owner)
 
 	if len(owner) == 0 {
 		return fmt.Errorf("owner is required")
@@ -59,16 +57,14 @@ func runDisableE(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("pat is required")
 	}
 
-	c := pkg.NewClient(http.DefaultClient, os.Getenv("ACTUATED_URL"))
-
-	res, status, err := c.DisableAgent(pat, owner, host, staff)
+	c, err := newClient(cmd)
 	if err != nil {
 		return err
 	}
 
-	if status != http.StatusOK && status != http.StatusAccepted &&
-		status != http.StatusNoContent && status != http.StatusCreated {
-		return fmt.Errorf("unexpected status code: %d, error: %s", status, res)
+	res, status, err := c.DisableAgent(cmd.Context(), pat, owner, host, staff)
+	if err != nil {
+		return err
 	}
 
 	fmt.Printf("Disable requested for %s, status: %d\n", owner, status)
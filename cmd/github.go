@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/v76/github"
+	"github.com/self-actuated/actuated-cli/pkg"
+	"github.com/spf13/cobra"
+	"golang.org/x/oauth2"
+)
+
+// defaultGitHubHost is used unless --github-host, ACTUATED_GITHUB_HOST or
+// the github-host of the active context is set
+const defaultGitHubHost = "github.com"
+
+// githubHost returns the GitHub or GitHub Enterprise Server host from the
+// --github-host flag, ACTUATED_GITHUB_HOST, or the active context.
+func githubHost(cmd *cobra.Command) string {
+	if f := cmd.Root().PersistentFlags().Lookup("github-host"); f != nil && f.Changed {
+		return normaliseHost(f.Value.String())
+	}
+
+	if v := os.Getenv("ACTUATED_GITHUB_HOST"); len(v) > 0 {
+		return normaliseHost(v)
+	}
+
+	if activeContext != nil && len(activeContext.GitHubHost) > 0 {
+		return normaliseHost(activeContext.GitHubHost)
+	}
+
+	return defaultGitHubHost
+}
+
+// normaliseHost accepts a host given as a URL i.e. https://github.example.com/
+func normaliseHost(host string) string {
+	host = strings.TrimPrefix(host, "https://")
+	host = strings.TrimPrefix(host, "http://")
+	return strings.TrimSuffix(host, "/")
+}
+
+// isEnterpriseHost reports whether the host is a GitHub Enterprise Server
+func isEnterpriseHost(host string) bool {
+	return host != defaultGitHubHost
+}
+
+// jobURL returns the URL of the job on the configured GitHub host.
+func jobURL(cmd *cobra.Command, job pkg.JobStatus) string {
+	return job.URLFieldForHost(githubHost(cmd))
+}
+
+// newGitHubClient returns a client for the GitHub API which authenticates
+// with pat, and uses the same timeout and debug settings as the other
+// HTTP requests made by the CLI.
+func newGitHubClient(cmd *cobra.Command, pat string) (*github.Client, error) {
+	httpClient, err := newHTTPClient(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.WithValue(cmd.Context(), oauth2.HTTPClient, httpClient)
+	ts := oauth2.StaticTokenSource(
+		&oauth2.Token{AccessToken: pat},
+	)
+	tc := oauth2.NewClient(ctx, ts)
+	tc.Timeout = httpClient.Timeout
+
+	client := github.NewClient(tc)
+
+	if host := githubHost(cmd); isEnterpriseHost(host) {
+		return client.WithEnterpriseURLs("https://"+host+"/api/v3/", "https://"+host+"/api/uploads/")
+	}
+
+	return client, nil
+}
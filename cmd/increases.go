@@ -4,12 +4,10 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"os"
 	"strings"
 	"time"
 
-	"github.com/self-actuated/actuated-cli/pkg"
 	"github.com/spf13/cobra"
 )
 
@@ -22,7 +20,7 @@ func makeIncreases() *cobra.Command {
 	cmd.RunE = runIncreasesE
 
 	cmd.Flags().Int("days", 30, "The number of days to look back for increases")
-	cmd.Flags().BoolP("json", "j", false, "Request output in JSON format")
+	addOutputFlags(cmd)
 
 	return cmd
 }
@@ -33,18 +31,19 @@ func runIncreasesE(cmd *cobra.Command, args []string) error {
 	if len(args) == 1 {
 		owner = strings.TrimSpace(args[0])
 	}
+	owner = getOwner(owner)
 
 	pat, err := getPat(cmd)
 	if err != nil {
 		return err
 	}
 
-	staff, err := cmd.Flags().GetBool("staff")
+	staff, err := getStaff(cmd)
 	if err != nil {
 		return err
 	}
 
-	requestJson, err := cmd.Flags().GetBool("json")
+	output, err := getOutputFormat(cmd)
 	if err != nil {
 		return err
 	}
@@ -53,7 +52,10 @@ func runIncreasesE(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("pat is required")
 	}
 
-	c := pkg.NewClient(http.DefaultClient, os.Getenv("ACTUATED_URL"))
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
 	days, err := cmd.Flags().GetInt("days")
 	if err != nil {
 		return err
@@ -61,32 +63,46 @@ func runIncreasesE(cmd *cobra.Command, args []string) error {
 
 	startDate := time.Now().Add(-1 * time.Duration(days) * 24 * time.Hour)
 
-	res, status, err := c.GetBuildIncreases(pat, owner, startDate, staff, requestJson)
-	if err != nil {
-		return err
-	}
+	ctx := cmd.Context()
 
-	if status != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d, message: %s", status, string(res))
-	}
+	// The table and JSON formats print the server's response as it is, as
+	// they did before -o was added
+	switch output.Name {
+	case outputTable, outputWide:
+		res, _, err := c.GetBuildIncreasesRaw(ctx, pat, owner, startDate, staff, false)
+		if err != nil {
+			return err
+		}
 
-	if requestJson {
+		yr, isoWeek := startDate.ISOWeek()
+		fmt.Printf("Start date: %s\tWeek: %d (%d)\n",
+			startDate.Format("2006-01-02"),
+			isoWeek,
+			yr)
+		fmt.Println(res)
+		return nil
 
-		var prettyJSON bytes.Buffer
-		err := json.Indent(&prettyJSON, []byte(res), "", "  ")
+	case outputJSON:
+		res, _, err := c.GetBuildIncreasesRaw(ctx, pat, owner, startDate, staff, true)
 		if err != nil {
 			return err
 		}
-		res = prettyJSON.String()
-	}
 
-	yr, isoWeek := startDate.ISOWeek()
-	fmt.Printf("Start date: %s\tWeek: %d (%d)\n",
-		startDate.Format("2006-01-02"),
-		isoWeek,
-		yr)
-	fmt.Println(res)
+		var prettyJSON bytes.Buffer
+		if err := json.Indent(&prettyJSON, []byte(res), "", "  "); err != nil {
+			return err
+		}
+		fmt.Println(prettyJSON.String())
+		return nil
+	}
 
-	return nil
+	increases, err := c.GetBuildIncreases(ctx, pat, owner, startDate, staff)
+	if err != nil {
+		return err
+	}
 
+	return printOutput(os.Stdout, output, increases.Data, nil,
+		func() ([]string, [][]string) {
+			return genericRecords(increases.Data)
+		})
 }
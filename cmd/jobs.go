@@ -1,14 +1,15 @@
 package cmd
 
 import (
-	"encoding/json"
+	"bytes"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/morikuni/aec"
 	"github.com/olekukonko/tablewriter"
 	"github.com/self-actuated/actuated-cli/pkg"
 	"github.com/spf13/cobra"
@@ -45,8 +46,23 @@ end if you reach out to support.
   actuated-cli jobs ORG
   
   # Get the same result, but in JSON format
-  actuated-cli jobs ORG --json
+  actuated-cli jobs ORG -o json
+
+  # Print the URL of each queued job
+  actuated-cli jobs -o 'jsonpath={range [?(@.status=="queued")]}{.url}{"\n"}{end}'
+
+  # Export the jobs to a spreadsheet
+  actuated-cli jobs -o csv > jobs.csv
   
+  # Show jobs which have been queued for more than 10 minutes, oldest first
+  actuated-cli jobs --status queued --queued-longer-than 10m --sort-by queued
+
+  # Show jobs for the repos starting with "api-" which need an arm64 runner
+  actuated-cli jobs --repo 'api-*' --label arm64
+
+  # Watch the queue, polling every 10 seconds
+  actuated-cli jobs --watch --interval 10s
+
   # Check queued and in_progress jobs for a customer
   actuated-cli jobs --staff CUSTOMER
 `,
@@ -54,8 +70,21 @@ end if you reach out to support.
 
 	cmd.RunE = runJobsE
 
-	cmd.Flags().BoolP("verbose", "v", false, "Show URLs")
-	cmd.Flags().BoolP("json", "j", false, "Request output in JSON format")
+	cmd.Flags().BoolP("verbose", "v", false, "Show URLs, the same as -o wide")
+	cmd.Flags().BoolP("watch", "w", false, "Watch the queue, redrawing the table as jobs are queued, started and finish")
+	cmd.Flags().Duration("interval", time.Second*5, "Interval between polls of the API for --watch")
+	cmd.Flags().Bool("steps", false, "Show the step each running job is executing, from the GitHub API, implies -o wide")
+	addOutputFlags(cmd)
+	addJobFilterFlags(cmd)
+	addJobSortFlags(cmd)
+
+	cmd.AddCommand(makeJobsStats())
+	cmd.AddCommand(makeJobsCheck())
+	cmd.AddCommand(makeJobsReconcile())
+	cmd.AddCommand(makeJobsCancel())
+	cmd.AddCommand(makeJobsRerun())
+	cmd.AddCommand(makeJobsDescribe())
+	cmd.AddCommand(makeJobsWait())
 
 	return cmd
 }
@@ -66,18 +95,19 @@ func runJobsE(cmd *cobra.Command, args []string) error {
 	if len(args) == 1 {
 		owner = strings.TrimSpace(args[0])
 	}
+	owner = getOwner(owner)
 
 	pat, err := getPat(cmd)
 	if err != nil {
 		return err
 	}
 
-	staff, err := cmd.Flags().GetBool("staff")
+	staff, err := getStaff(cmd)
 	if err != nil {
 		return err
 	}
 
-	requestJson, err := cmd.Flags().GetBool("json")
+	output, err := getOutputFormat(cmd)
 	if err != nil {
 		return err
 	}
@@ -87,54 +117,134 @@ func runJobsE(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	if len(pat) == 0 {
-		return fmt.Errorf("pat is required")
+	watch, err := cmd.Flags().GetBool("watch")
+	if err != nil {
+		return err
 	}
 
-	c := pkg.NewClient(http.DefaultClient, os.Getenv("ACTUATED_URL"))
+	interval, err := cmd.Flags().GetDuration("interval")
+	if err != nil {
+		return err
+	}
 
-	acceptJSON := true
+	showSteps, err := cmd.Flags().GetBool("steps")
+	if err != nil {
+		return err
+	}
 
-	res, status, err := c.ListJobs(pat, owner, staff, acceptJSON)
+	filter, err := getJobFilter(cmd)
 	if err != nil {
 		return err
 	}
 
-	if status != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d, message: %s", status, string(res))
+	if len(pat) == 0 {
+		return fmt.Errorf("pat is required")
 	}
 
-	if requestJson {
-		var statuses []JobStatus
-		if err := json.Unmarshal([]byte(res), &statuses); err != nil {
-			return err
+	if showSteps {
+		if output.Name != outputTable && output.Name != outputWide {
+			return fmt.Errorf("--steps can only be used with the table or wide output")
 		}
+		// Each running job is looked up on GitHub, which is too many
+		// requests to make on every poll
+		if watch {
+			return fmt.Errorf("--steps can't be used with --watch")
+		}
+	}
 
-		// Populate client-side fields (e.g. the GitHub job URL) so the JSON
-		// output is the full object, matching what the verbose table view
-		// shows rather than just the raw server payload.
-		for i := range statuses {
-			statuses[i].URL = statuses[i].URLField()
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	if watch {
+		if output.Name != outputTable && output.Name != outputWide {
+			return fmt.Errorf("--watch can only be used with the table or wide output")
+		}
+		if interval < minWatchInterval {
+			return fmt.Errorf("--interval must be at least %s", minWatchInterval)
 		}
 
-		out, err := json.MarshalIndent(statuses, "", "  ")
+		return watchJobs(cmd, c, pat, owner, staff, filter, verbose || output.wide(), interval)
+	}
+
+	statuses, err := c.ListJobs(cmd.Context(), pat, owner, staff)
+	if err != nil {
+		return err
+	}
+
+	statuses = filter.apply(statuses, time.Now())
+
+	// Populate client-side fields (e.g. the GitHub job URL) so the JSON
+	// output is the full object, matching what the verbose table view
+	// shows rather than just the raw server payload.
+	for i := range statuses {
+		statuses[i].URL = jobURL(cmd, statuses[i])
+	}
+
+	if (verbose || showSteps) && output.Name == outputTable {
+		output.Name = outputWide
+	}
+
+	var steps map[int64]string
+	if showSteps {
+		ghClient, err := newGitHubClient(cmd, pat)
 		if err != nil {
 			return err
 		}
-		fmt.Println(string(out))
-	} else {
+		steps = jobSteps(cmd, ghClient, statuses)
+	}
 
-		var statuses []JobStatus
+	return printOutput(os.Stdout, output, statuses,
+		func(w io.Writer, wide bool) error {
+			buf := &bytes.Buffer{}
+			printEvents(buf, statuses, wide, nil, steps)
 
-		if err := json.Unmarshal([]byte(res), &statuses); err != nil {
+			out := buf.Bytes()
+			if !useColour(w) {
+				out = stripColour(out)
+			}
+			_, err := w.Write(out)
 			return err
-		}
+		},
+		func() ([]string, [][]string) {
+			return jobRecords(statuses)
+		})
+}
+
+// jobRecords returns a row per job for CSV output.
+func jobRecords(statuses []pkg.JobStatus) ([]string, [][]string) {
+	header := []string{"job_id", "owner", "repo", "workflow_name", "job_name", "actor",
+		"status", "runner_name", "agent_name", "labels", "queued_at", "started_at", "url"}
 
-		printEvents(os.Stdout, statuses, verbose)
+	rows := [][]string{}
+	for _, status := range statuses {
+		rows = append(rows, []string{
+			strconv.FormatInt(status.JobID, 10),
+			status.Owner,
+			status.Repo,
+			status.WorkflowName,
+			status.JobName,
+			status.Actor,
+			status.Status,
+			status.RunnerName,
+			status.AgentName,
+			strings.Join(status.Labels, ","),
+			formatTime(status.QueuedAt),
+			formatTime(status.StartedAt),
+			status.URL,
+		})
 	}
 
-	return nil
+	return header, rows
+}
 
+// formatTime returns t in RFC3339, or an empty string when t is nil.
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
 }
 
 // progressBar generates a progress bar using soft block characters
@@ -166,12 +276,115 @@ func progressBar(progress float64, width int) string {
 	return "[" + bar + "]"
 }
 
-func printEvents(w io.Writer, statuses []JobStatus, verbose bool) {
+// Thresholds for the colour of the WAIT and OVERRUN columns, a long wait
+// means a job is starved of runners, whilst an overrun means it is slower
+// than usual.
+const (
+	waitWarning     = time.Minute * 2
+	waitCritical    = time.Minute * 10
+	overrunWarning  = 25
+	overrunCritical = 100
+)
+
+// jobWait returns how long the job was queued for before it started, or
+// how long it has been queued for so far. ok is false when the time the job
+// was queued is not known.
+func jobWait(status pkg.JobStatus, now time.Time) (time.Duration, bool) {
+	queuedAt := jobQueuedAt(status)
+	if queuedAt == nil {
+		return 0, false
+	}
+
+	var wait time.Duration
+	switch {
+	case status.StartedAt != nil:
+		wait = status.StartedAt.Sub(*queuedAt)
+	case status.Status == "queued":
+		wait = now.Sub(*queuedAt)
+	default:
+		return 0, false
+	}
+
+	// Clocks on the server may differ slightly from GitHub's
+	if wait < 0 {
+		return 0, false
+	}
+	return wait, true
+}
+
+// jobElapsed returns how long a job has been running for.
+func jobElapsed(status pkg.JobStatus, now time.Time) (time.Duration, bool) {
+	if status.Status == "queued" || status.StartedAt == nil {
+		return 0, false
+	}
+	return now.Sub(*status.StartedAt), true
+}
+
+// jobOverrun returns the percentage by which a running job has exceeded
+// its average runtime, ok is false until it has.
+func jobOverrun(status pkg.JobStatus, now time.Time) (float64, bool) {
+	elapsed, ok := jobElapsed(status, now)
+	if !ok || status.AverageRuntime <= 0 || elapsed <= status.AverageRuntime {
+		return 0, false
+	}
+	return (float64(elapsed)/float64(status.AverageRuntime) - 1) * 100, true
+}
+
+// thresholdColour colours v yellow when value reaches warning, and red when
+// it reaches critical. Colours for the thresholds would be lost within a
+// highlighted row, so v is left as it is when highlighted is set.
+func thresholdColour(v string, value, warning, critical float64, highlighted bool) string {
+	switch {
+	case highlighted:
+		return v
+	case value >= critical:
+		return aec.RedF.Apply(v)
+	case value >= warning:
+		return aec.YellowF.Apply(v)
+	}
+	return v
+}
+
+func formatWait(status pkg.JobStatus, now time.Time, highlighted bool) string {
+	wait, ok := jobWait(status, now)
+	if !ok {
+		return "-"
+	}
+
+	return thresholdColour(wait.Round(time.Second).String(),
+		float64(wait), float64(waitWarning), float64(waitCritical), highlighted)
+}
+
+func formatElapsed(status pkg.JobStatus, now time.Time) string {
+	elapsed, ok := jobElapsed(status, now)
+	if !ok {
+		return ""
+	}
+	return elapsed.Round(time.Second).String()
+}
+
+func formatOverrun(status pkg.JobStatus, now time.Time, highlighted bool) string {
+	overrun, ok := jobOverrun(status, now)
+	if !ok {
+		return ""
+	}
+
+	return thresholdColour(fmt.Sprintf("+%.0f%%", overrun),
+		overrun, overrunWarning, overrunCritical, highlighted)
+}
+
+// printEvents renders the jobs as a table, changes highlights the jobs
+// which were queued, started or have gone since the last poll of --watch.
+// steps adds a STEP column to the verbose table, with the executing step of
+// each running job.
+func printEvents(w io.Writer, statuses []pkg.JobStatus, verbose bool, changes map[int64]jobChange, steps map[int64]string) {
 	table := tablewriter.NewWriter(w)
 
 	// Set up headers - ETA column shows status implicitly (Queued or progress bar)
-	if verbose {
-		table.SetHeader([]string{"OWNER/REPO", "JOB/WORKFLOW", "RUNNER/SERVER", "ETA", "LABELS", "URL"})
+	if verbose && steps != nil {
+		table.SetHeader([]string{"OWNER/REPO", "JOB/WORKFLOW", "RUNNER/SERVER", "ETA", "WAIT", "ELAPSED", "OVERRUN", "STEP", "LABELS", "URL"})
+	} else if verbose {
+		table.SetHeader([]string{"OWNER/REPO", "JOB/WORKFLOW", "RUNNER/SERVER", "ETA", "WAIT", "ELAPSED", "OVERRUN", "LABELS", "URL"})
 	} else {
 		table.SetHeader([]string{"OWNER/REPO", "JOB/WORKFLOW", "RUNNER/SERVER", "ETA", "LABELS"})
 	}
@@ -185,6 +398,8 @@ func printEvents(w io.Writer, statuses []JobStatus, verbose bool) {
 	table.SetAutoFormatHeaders(false)
 	table.SetRowLine(true)
 
+	now := time.Now()
+
 	for _, status := range statuses {
 		// Line 1 values
 		owner := status.Owner + "/"
@@ -198,7 +413,12 @@ func printEvents(w io.Writer, statuses []JobStatus, verbose bool) {
 		workflow := status.WorkflowName
 		server := status.AgentName
 
-		if status.Status == "queued" {
+		change := changes[status.JobID]
+
+		if change == jobGone {
+			// Finished or cancelled since the last poll
+			etaLine1 = "Gone"
+		} else if status.Status == "queued" {
 			// Queued jobs show "Queued" with empty progress bar
 			etaLine1 = "Queued"
 			etaLine2 = progressBar(0, 10)
@@ -226,63 +446,38 @@ func printEvents(w io.Writer, statuses []JobStatus, verbose bool) {
 			etaLine2 = ""
 		}
 
-		url := status.URLField()
+		url := status.URL
+		if len(url) == 0 {
+			url = status.URLField()
+		}
 		labels := ""
 		if len(status.Labels) > 0 {
 			labels = strings.Join(status.Labels, ",")
 		}
 
 		// Use newlines to create two-line cells
+		row := []string{
+			owner + "\n" + repo,
+			job + "\n" + workflow,
+			runner + "\n" + server,
+			etaLine1 + "\n" + etaLine2,
+		}
 		if verbose {
-			table.Append([]string{
-				owner + "\n" + repo,
-				job + "\n" + workflow,
-				runner + "\n" + server,
-				etaLine1 + "\n" + etaLine2,
-				labels,
-				url,
-			})
+			highlighted := change != jobUnchanged
+			row = append(row,
+				formatWait(status, now, highlighted),
+				formatElapsed(status, now),
+				formatOverrun(status, now, highlighted))
+			if steps != nil {
+				row = append(row, steps[status.JobID])
+			}
+			row = append(row, labels, url)
 		} else {
-			table.Append([]string{
-				owner + "\n" + repo,
-				job + "\n" + workflow,
-				runner + "\n" + server,
-				etaLine1 + "\n" + etaLine2,
-				labels,
-			})
+			row = append(row, labels)
 		}
+
+		table.Append(change.highlight(row))
 	}
 
 	table.Render()
 }
-
-type JobStatus struct {
-	JobID        int64  `json:"job_id"`
-	Owner        string `json:"owner"`
-	Repo         string `json:"repo"`
-	WorkflowName string `json:"workflow_name"`
-	JobName      string `json:"job_name"`
-	Actor        string `json:"actor,omitempty"`
-
-	RunnerName string   `json:"runner_name,omitempty"`
-	Status     string   `json:"status"`
-	Conclusion string   `json:"conclusion,omitempty"`
-	Labels     []string `json:"labels,omitempty"`
-	URL        string   `json:"url,omitempty"`
-
-	UpdatedAt   *time.Time `json:"updated_at"`
-	StartedAt   *time.Time `json:"startedAt,omitempty"`
-	CompletedAt *time.Time `json:"completedAt,omitempty"`
-
-	AgentName string `json:"agent_name,omitempty"`
-
-	AverageRuntime time.Duration `json:"averageRuntime,omitempty"`
-
-	QueuedAt *time.Time `json:"queuedAt,omitempty"`
-}
-
-// URLField returns the GitHub URL for the job run, constructed client-side
-// from the owner, repo and job ID.
-func (j JobStatus) URLField() string {
-	return fmt.Sprintf("https://github.com/%s%s/runs/%d", j.Owner+"/", j.Repo, j.JobID)
-}
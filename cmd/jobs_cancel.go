@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/google/go-github/v76/github"
+	"github.com/self-actuated/actuated-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+func makeJobsCancel() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cancel [JOB_ID|URL...]",
+		Short: "Cancel the workflow runs of jobs on GitHub",
+		Long: `Cancel jobs given by their ID or URL, or select jobs from the build queue
+with the same filters as "actuated-cli jobs".
+
+GitHub can only cancel a whole workflow run, so any other jobs in the same
+run will be cancelled too.
+
+A job ID which is no longer in the build queue can be given along with its
+repo as --repo OWNER/REPO.
+
+Jobs selected with filters, and jobs whose workflow run holds other jobs
+which have not completed, are listed along with those jobs, then you will
+be asked to confirm, use --yes to skip the prompt.`,
+		Example: `  # Cancel a job from the queue by its ID
+  actuated-cli jobs cancel 21234567890
+
+  # Cancel a job by its URL
+  actuated-cli jobs cancel https://github.com/acme/api/actions/runs/1234/job/5678
+
+  # Cancel all the jobs for a repo which have been queued for over an hour
+  actuated-cli jobs cancel --repo acme/api --queued-longer-than 1h
+`,
+	}
+
+	cmd.RunE = runJobsCancelE
+
+	cmd.Flags().BoolP("yes", "y", false, "Cancel without asking for confirmation")
+	addJobFilterFlags(cmd)
+
+	return cmd
+}
+
+func runJobsCancelE(cmd *cobra.Command, args []string) error {
+	jobs, bulk, err := selectJobs(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	if len(jobs) == 0 {
+		fmt.Println("No jobs matched the filters")
+		return nil
+	}
+
+	pat, err := getPat(cmd)
+	if err != nil {
+		return err
+	}
+
+	ghClient, err := newGitHubClient(cmd, pat)
+	if err != nil {
+		return err
+	}
+
+	// Jobs in the same run are cancelled together, so find the runs first
+	groups, failed := groupByRun(cmd, ghClient, jobs)
+
+	runs := []jobRef{}
+	for _, group := range groups {
+		runs = append(runs, group[0])
+	}
+
+	affected, err := listRunJobs(cmd, ghClient, runs)
+	if err != nil {
+		return err
+	}
+
+	selected := map[int64]bool{}
+	for _, job := range jobs {
+		selected[job.JobID] = true
+	}
+	others := slices.ContainsFunc(affected, func(job jobRef) bool {
+		return !selected[job.JobID]
+	})
+
+	if bulk || others {
+		if !bulk {
+			fmt.Fprintf(os.Stderr, "Cancelling the workflow run also cancels its other jobs:\n")
+		}
+		ok, err := confirmJobs(cmd, "cancel", affected)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("aborted")
+		}
+	}
+
+	for _, group := range groups {
+		job := group[0]
+		if _, err := ghClient.Actions.CancelWorkflowRunByID(cmd.Context(), job.Owner, job.Repo, job.RunID); err != nil && !isAccepted(err) {
+			fmt.Fprintf(os.Stderr, "unable to cancel workflow run %d for %s: %s\n", job.RunID, job, err)
+			failed += len(group)
+			continue
+		}
+
+		for _, job := range group {
+			fmt.Printf("Cancelled workflow run %d for %s\n", job.RunID, job)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d jobs could not be cancelled", failed, len(jobs))
+	}
+
+	return nil
+}
+
+// listRunJobs returns the jobs which have not completed in each workflow
+// run, which are all cancelled along with the run.
+func listRunJobs(cmd *cobra.Command, ghClient *github.Client, runs []jobRef) ([]jobRef, error) {
+	jobs := []jobRef{}
+	for _, run := range runs {
+		runJobs, err := listWorkflowJobs(cmd, ghClient, run.Owner, run.Repo, run.RunID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, job := range runJobs {
+			if job.GetStatus() == "completed" {
+				continue
+			}
+			jobs = append(jobs, jobRef{
+				Owner: run.Owner,
+				Repo:  run.Repo,
+				JobID: job.GetID(),
+				RunID: run.RunID,
+				Name:  jobName(pkg.JobStatus{WorkflowName: job.GetWorkflowName(), JobName: job.GetName()}),
+			})
+		}
+	}
+
+	return jobs, nil
+}
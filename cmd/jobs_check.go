@@ -0,0 +1,302 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/self-actuated/actuated-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes used by Nagios and Icinga plugins
+const (
+	checkOK       = 0
+	checkWarning  = 1
+	checkCritical = 2
+	checkUnknown  = 3
+)
+
+var checkStates = map[int]string{
+	checkOK:       "OK",
+	checkWarning:  "WARNING",
+	checkCritical: "CRITICAL",
+	checkUnknown:  "UNKNOWN",
+}
+
+func makeJobsCheck() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check [OWNER]",
+		Short: "Check for stuck jobs, for use with Nagios or Icinga",
+		Long: `Check for jobs which have been queued for too long, or which are running
+for much longer than their average runtime, such as jobs which GitHub still
+reports as in_progress after they have finished.
+
+The output and exit code follow the Nagios plugin guidelines, so the command
+can be used as a check in Nagios, Icinga, or any monitoring system which
+supports them:
+
+  0 OK, 1 WARNING, 2 CRITICAL, 3 UNKNOWN i.e. the API could not be reached
+
+The first line is the status with perfdata, followed by a line for each job
+over a threshold. Set a threshold to 0 to disable it.`,
+		Example: `  # Critical when a job is queued for 15m, or runs for 3x its average
+  actuated-cli jobs check --max-queued 15m --max-overrun 3x
+
+  # Only check the jobs for one repo, and ignore overruns
+  actuated-cli jobs check --repo acme/api --max-overrun 0 --warn-overrun 0
+`,
+		Args: cobra.MaximumNArgs(1),
+	}
+
+	cmd.RunE = runJobsCheckE
+
+	cmd.Flags().Duration("warn-queued", time.Minute*5, "Warning when a job has been queued for longer than this")
+	cmd.Flags().Duration("max-queued", time.Minute*15, "Critical when a job has been queued for longer than this")
+	cmd.Flags().String("warn-overrun", "2x", "Warning when a job has been running for longer than this multiple of its average runtime")
+	cmd.Flags().String("max-overrun", "3x", "Critical when a job has been running for longer than this multiple of its average runtime")
+	cmd.Flags().Duration("max-running", 0, "Critical when a job has been running for longer than this, for jobs without an average runtime")
+	addJobFilterFlags(cmd)
+
+	return cmd
+}
+
+// checkThresholds are the limits for "jobs check", a value of 0 turns a
+// threshold off.
+type checkThresholds struct {
+	WarnQueued  time.Duration
+	MaxQueued   time.Duration
+	WarnOverrun float64
+	MaxOverrun  float64
+	MaxRunning  time.Duration
+}
+
+// checkProblem is a job over a threshold.
+type checkProblem struct {
+	State  int
+	Job    pkg.JobStatus
+	Reason string
+}
+
+func runJobsCheckE(cmd *cobra.Command, args []string) error {
+	// Invalid thresholds are UNKNOWN, as per the plugin guidelines
+	thresholds, err := getCheckThresholds(cmd)
+	if err != nil {
+		return checkUnknownError(err)
+	}
+
+	filter, err := getJobFilter(cmd)
+	if err != nil {
+		return checkUnknownError(err)
+	}
+
+	var owner string
+	if len(args) == 1 {
+		owner = strings.TrimSpace(args[0])
+	}
+	owner = getOwner(owner)
+
+	statuses, err := listJobsForCheck(cmd, owner)
+	if err != nil {
+		return checkUnknownError(err)
+	}
+
+	now := time.Now()
+	statuses = filter.apply(statuses, now)
+	for i := range statuses {
+		statuses[i].URL = jobURL(cmd, statuses[i])
+	}
+
+	state := printJobsCheck(os.Stdout, statuses, thresholds, now)
+	if state == checkOK {
+		return nil
+	}
+
+	return &ExitError{Code: state}
+}
+
+// checkUnknownError prints the UNKNOWN status line for err, and exits with
+// the code for UNKNOWN.
+func checkUnknownError(err error) error {
+	fmt.Printf("JOBS UNKNOWN - %s\n", err)
+	return &ExitError{Code: checkUnknown}
+}
+
+func listJobsForCheck(cmd *cobra.Command, owner string) ([]pkg.JobStatus, error) {
+	pat, err := getPat(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	staff, err := getStaff(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pat) == 0 {
+		return nil, fmt.Errorf("pat is required")
+	}
+
+	c, err := newClient(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.ListJobs(cmd.Context(), pat, owner, staff)
+}
+
+func getCheckThresholds(cmd *cobra.Command) (checkThresholds, error) {
+	t := checkThresholds{}
+	flags := cmd.Flags()
+
+	var err error
+	if t.WarnQueued, err = flags.GetDuration("warn-queued"); err != nil {
+		return t, err
+	}
+	if t.MaxQueued, err = flags.GetDuration("max-queued"); err != nil {
+		return t, err
+	}
+	if t.MaxRunning, err = flags.GetDuration("max-running"); err != nil {
+		return t, err
+	}
+
+	for name, v := range map[string]*float64{"warn-overrun": &t.WarnOverrun, "max-overrun": &t.MaxOverrun} {
+		s, err := flags.GetString(name)
+		if err != nil {
+			return t, err
+		}
+		if *v, err = parseMultiplier(s); err != nil {
+			return t, fmt.Errorf("invalid --%s: %w", name, err)
+		}
+	}
+
+	return t, nil
+}
+
+// parseMultiplier parses a multiple such as "3x" or "2.5".
+func parseMultiplier(s string) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "x"), 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("%q is not a multiple such as 3x", s)
+	}
+	return v, nil
+}
+
+// checkJob returns the worst state of the job, along with the reason.
+func checkJob(status pkg.JobStatus, t checkThresholds, now time.Time) (int, string) {
+	if status.Status == "queued" {
+		wait, ok := jobWait(status, now)
+		if !ok {
+			return checkOK, ""
+		}
+
+		reason := fmt.Sprintf("queued for %s", wait.Round(time.Second))
+		switch {
+		case t.MaxQueued > 0 && wait > t.MaxQueued:
+			return checkCritical, reason
+		case t.WarnQueued > 0 && wait > t.WarnQueued:
+			return checkWarning, reason
+		}
+		return checkOK, ""
+	}
+
+	elapsed, ok := jobElapsed(status, now)
+	if !ok {
+		return checkOK, ""
+	}
+
+	reason := fmt.Sprintf("running for %s", elapsed.Round(time.Second))
+
+	if status.AverageRuntime > 0 {
+		multiple := float64(elapsed) / float64(status.AverageRuntime)
+		reason = fmt.Sprintf("%s, %.1fx its average of %s", reason, multiple, status.AverageRuntime.Round(time.Second))
+
+		switch {
+		case t.MaxOverrun > 0 && multiple > t.MaxOverrun:
+			return checkCritical, reason
+		case t.WarnOverrun > 0 && multiple > t.WarnOverrun:
+			return checkWarning, reason
+		}
+	} else if t.MaxRunning > 0 && elapsed > t.MaxRunning {
+		return checkCritical, reason
+	}
+
+	return checkOK, ""
+}
+
+// printJobsCheck writes the status line with perfdata, then a line for each
+// job over a threshold, and returns the overall state.
+func printJobsCheck(w io.Writer, statuses []pkg.JobStatus, t checkThresholds, now time.Time) int {
+	state := checkOK
+	problems := []checkProblem{}
+
+	queued, running := 0, 0
+	var oldestQueued time.Duration
+	var maxOverrun float64
+
+	for _, status := range statuses {
+		if status.Status == "queued" {
+			queued++
+			if wait, ok := jobWait(status, now); ok && wait > oldestQueued {
+				oldestQueued = wait
+			}
+		} else {
+			running++
+			if elapsed, ok := jobElapsed(status, now); ok && status.AverageRuntime > 0 {
+				maxOverrun = max(maxOverrun, float64(elapsed)/float64(status.AverageRuntime))
+			}
+		}
+
+		if jobState, reason := checkJob(status, t, now); jobState != checkOK {
+			problems = append(problems, checkProblem{State: jobState, Job: status, Reason: reason})
+			state = max(state, jobState)
+		}
+	}
+
+	summary := fmt.Sprintf("%d queued, %d running", queued, running)
+	if len(problems) > 0 {
+		counts := map[int]int{}
+		for _, p := range problems {
+			counts[p.State]++
+		}
+		summary = fmt.Sprintf("%d critical, %d warning, %s", counts[checkCritical], counts[checkWarning], summary)
+	}
+
+	perfdata := []string{
+		fmt.Sprintf("queued=%d;;;0;", queued),
+		fmt.Sprintf("running=%d;;;0;", running),
+		fmt.Sprintf("oldest_queued=%ds;%s;%s;0;", int(oldestQueued.Seconds()), perfSeconds(t.WarnQueued), perfSeconds(t.MaxQueued)),
+		fmt.Sprintf("max_overrun=%.2f;%s;%s;0;", maxOverrun, perfFloat(t.WarnOverrun), perfFloat(t.MaxOverrun)),
+	}
+
+	fmt.Fprintf(w, "JOBS %s - %s | %s\n", checkStates[state], summary, strings.Join(perfdata, " "))
+
+	for _, p := range problems {
+		job := p.Job.JobName
+		if len(p.Job.WorkflowName) > 0 {
+			job = p.Job.WorkflowName + "/" + job
+		}
+		fmt.Fprintf(w, "%s: %s/%s %s %s %s\n", checkStates[p.State],
+			p.Job.Owner, p.Job.Repo, job, p.Reason, p.Job.URL)
+	}
+
+	return state
+}
+
+// perfSeconds formats a threshold for perfdata, where 0 is left empty.
+func perfSeconds(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	return strconv.Itoa(int(d.Seconds()))
+}
+
+func perfFloat(v float64) string {
+	if v <= 0 {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
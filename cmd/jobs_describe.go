@@ -0,0 +1,449 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v76/github"
+	"github.com/self-actuated/actuated-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+// previousRunsLimit is the number of successful runs to search for the
+// previous run of a job
+const previousRunsLimit = 5
+
+func makeJobsDescribe() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "describe JOB_ID|URL",
+		Short: "Show the steps of a job from GitHub",
+		Long: `Show the steps of a job from the GitHub Actions API, with the step which is
+executing and how long each step took.
+
+Each step is compared with the previous successful run of the same job, on
+the same branch when there is one, so you can tell whether a slow job is
+stuck in checkout, restoring a cache or running tests.
+
+A job ID which is no longer in the build queue can be given along with its
+repo as --repo OWNER/REPO, or give the URL of the job.`,
+		Example: `  # Show the steps of a running job
+  actuated-cli jobs describe 21234567890
+
+  # Show the steps of a job which has finished
+  actuated-cli jobs describe 21234567890 --repo acme/api
+
+  # Show the steps of a job by its URL, in JSON format
+  actuated-cli jobs describe https://github.com/acme/api/actions/runs/1234/job/5678 -o json
+`,
+		Args: cobra.ExactArgs(1),
+	}
+
+	cmd.RunE = runJobsDescribeE
+
+	cmd.Flags().String("repo", "", "Repo of a job ID which is no longer in the build queue, as OWNER/REPO")
+	addOutputFlags(cmd)
+
+	return cmd
+}
+
+// jobDescription is a job and its steps from GitHub, durations are in
+// seconds.
+type jobDescription struct {
+	JobID        int64      `json:"job_id"`
+	RunID        int64      `json:"run_id"`
+	Owner        string     `json:"owner"`
+	Repo         string     `json:"repo"`
+	WorkflowName string     `json:"workflow_name"`
+	JobName      string     `json:"job_name"`
+	HeadBranch   string     `json:"head_branch,omitempty"`
+	RunnerName   string     `json:"runner_name,omitempty"`
+	Status       string     `json:"status"`
+	Conclusion   string     `json:"conclusion,omitempty"`
+	URL          string     `json:"url"`
+	StartedAt    *time.Time `json:"startedAt,omitempty"`
+	CompletedAt  *time.Time `json:"completedAt,omitempty"`
+
+	// PreviousURL is the previous successful run of the job, which the
+	// steps are compared with
+	PreviousURL string `json:"previousUrl,omitempty"`
+
+	Steps []stepDescription `json:"steps"`
+}
+
+type stepDescription struct {
+	Number      int64      `json:"number"`
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`
+	Conclusion  string     `json:"conclusion,omitempty"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+
+	DurationSeconds float64 `json:"durationSeconds"`
+
+	// PreviousSeconds is the duration of the step in the previous
+	// successful run, when it could be found
+	PreviousSeconds *float64 `json:"previousSeconds,omitempty"`
+}
+
+func runJobsDescribeE(cmd *cobra.Command, args []string) error {
+	output, err := getOutputFormat(cmd)
+	if err != nil {
+		return err
+	}
+
+	repo, err := cmd.Flags().GetString("repo")
+	if err != nil {
+		return err
+	}
+
+	filter := jobFilter{}
+	if len(repo) > 0 {
+		filter.Repos = []string{repo}
+	}
+
+	jobs, err := resolveJobs(cmd, args, filter)
+	if err != nil {
+		return err
+	}
+	job := jobs[0]
+
+	pat, err := getPat(cmd)
+	if err != nil {
+		return err
+	}
+
+	ghClient, err := newGitHubClient(cmd, pat)
+	if err != nil {
+		return err
+	}
+
+	ctx := cmd.Context()
+
+	ghJob, _, err := ghClient.Actions.GetWorkflowJobByID(ctx, job.Owner, job.Repo, job.JobID)
+	if err != nil {
+		return fmt.Errorf("unable to find %s on GitHub: %w", job, err)
+	}
+
+	// The comparison is optional, so a failure is only a warning
+	previous, err := findPreviousJob(ctx, ghClient, job.Owner, job.Repo, ghJob)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: unable to find the previous successful run: %s\n", err)
+	}
+
+	description := describeJob(job, ghJob, previous, time.Now())
+
+	return printOutput(os.Stdout, output, description,
+		func(w io.Writer, wide bool) error {
+			buf := &bytes.Buffer{}
+			printJobDescription(buf, description)
+
+			out := buf.Bytes()
+			if !useColour(w) {
+				out = stripColour(out)
+			}
+			_, err := w.Write(out)
+			return err
+		},
+		func() ([]string, [][]string) {
+			return []string{"number", "name", "status", "conclusion", "started_at", "completed_at", "duration_seconds", "previous_seconds"},
+				stepRecords(description.Steps)
+		})
+}
+
+// findPreviousJob returns the job with the same name from the most recent
+// successful run of the workflow, preferring runs on the same branch. nil is
+// returned when there is no such job.
+func findPreviousJob(ctx context.Context, client *github.Client, owner, repo string, job *github.WorkflowJob) (*github.WorkflowJob, error) {
+	run, _, err := client.Actions.GetWorkflowRunByID(ctx, owner, repo, job.GetRunID())
+	if err != nil {
+		return nil, err
+	}
+
+	branches := []string{job.GetHeadBranch()}
+	if len(job.GetHeadBranch()) > 0 {
+		branches = append(branches, "")
+	}
+
+	for _, branch := range branches {
+		runs, _, err := client.Actions.ListWorkflowRunsByID(ctx, owner, repo, run.GetWorkflowID(), &github.ListWorkflowRunsOptions{
+			Branch:      branch,
+			Status:      "success",
+			ListOptions: github.ListOptions{PerPage: previousRunsLimit},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range runs.WorkflowRuns {
+			if r.GetID() == job.GetRunID() {
+				continue
+			}
+
+			jobs, _, err := client.Actions.ListWorkflowJobs(ctx, owner, repo, r.GetID(), &github.ListWorkflowJobsOptions{
+				Filter:      "latest",
+				ListOptions: github.ListOptions{PerPage: 100},
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			for _, j := range jobs.Jobs {
+				if j.GetName() == job.GetName() && j.GetConclusion() == "success" {
+					return j, nil
+				}
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+func describeJob(job jobRef, ghJob, previous *github.WorkflowJob, now time.Time) jobDescription {
+	d := jobDescription{
+		JobID:        ghJob.GetID(),
+		RunID:        ghJob.GetRunID(),
+		Owner:        job.Owner,
+		Repo:         job.Repo,
+		WorkflowName: ghJob.GetWorkflowName(),
+		JobName:      ghJob.GetName(),
+		HeadBranch:   ghJob.GetHeadBranch(),
+		RunnerName:   ghJob.GetRunnerName(),
+		Status:       ghJob.GetStatus(),
+		Conclusion:   ghJob.GetConclusion(),
+		URL:          ghJob.GetHTMLURL(),
+		StartedAt:    ghJob.StartedAt.GetTime(),
+		CompletedAt:  ghJob.CompletedAt.GetTime(),
+		Steps:        []stepDescription{},
+	}
+
+	previousSteps := map[string]float64{}
+	if previous != nil {
+		d.PreviousURL = previous.GetHTMLURL()
+		for _, step := range previous.Steps {
+			if duration, ok := stepDuration(step, now); ok {
+				previousSteps[step.GetName()] = duration.Round(time.Second).Seconds()
+			}
+		}
+	}
+
+	for _, step := range ghJob.Steps {
+		s := stepDescription{
+			Number:      step.GetNumber(),
+			Name:        step.GetName(),
+			Status:      step.GetStatus(),
+			Conclusion:  step.GetConclusion(),
+			StartedAt:   step.StartedAt.GetTime(),
+			CompletedAt: step.CompletedAt.GetTime(),
+		}
+
+		if duration, ok := stepDuration(step, now); ok {
+			s.DurationSeconds = duration.Round(time.Second).Seconds()
+		}
+
+		if seconds, ok := previousSteps[s.Name]; ok {
+			s.PreviousSeconds = &seconds
+		}
+
+		d.Steps = append(d.Steps, s)
+	}
+
+	return d
+}
+
+// stepDuration returns how long a step took, or how long it has been
+// running for so far.
+func stepDuration(step *github.TaskStep, now time.Time) (time.Duration, bool) {
+	startedAt := step.StartedAt.GetTime()
+	if startedAt == nil || startedAt.IsZero() {
+		return 0, false
+	}
+
+	if completedAt := step.CompletedAt.GetTime(); completedAt != nil && !completedAt.IsZero() {
+		return completedAt.Sub(*startedAt), true
+	}
+	if step.GetStatus() == "in_progress" {
+		return now.Sub(*startedAt), true
+	}
+	return 0, false
+}
+
+// currentStep returns the step which is executing, or nil when no step is.
+func currentStep(job *github.WorkflowJob) *github.TaskStep {
+	for _, step := range job.Steps {
+		if step.GetStatus() == "in_progress" {
+			return step
+		}
+	}
+	return nil
+}
+
+func printJobDescription(w io.Writer, d jobDescription) {
+	status := d.Status
+	if len(d.Conclusion) > 0 {
+		status += " (" + d.Conclusion + ")"
+	}
+
+	job := d.JobName
+	if len(d.WorkflowName) > 0 {
+		job = d.WorkflowName + "/" + job
+	}
+
+	fmt.Fprintf(w, "Job: %s/%s %s\n", d.Owner, d.Repo, job)
+	fmt.Fprintf(w, "Status: %s\n", status)
+	if len(d.HeadBranch) > 0 {
+		fmt.Fprintf(w, "Branch: %s\n", d.HeadBranch)
+	}
+	if len(d.RunnerName) > 0 {
+		fmt.Fprintf(w, "Runner: %s\n", d.RunnerName)
+	}
+	if d.StartedAt != nil {
+		fmt.Fprintf(w, "Started: %s (%s ago)\n", d.StartedAt.Format(time.RFC3339), time.Since(*d.StartedAt).Round(time.Second))
+	}
+	fmt.Fprintf(w, "URL: %s\n", d.URL)
+	if len(d.PreviousURL) > 0 {
+		fmt.Fprintf(w, "Compared with: %s\n", d.PreviousURL)
+	} else {
+		fmt.Fprintf(w, "Compared with: no previous successful run\n")
+	}
+	fmt.Fprintln(w)
+
+	if len(d.Steps) == 0 {
+		fmt.Fprintln(w, "No steps have started")
+		return
+	}
+
+	rows := [][]string{}
+	for _, step := range d.Steps {
+		status := step.Status
+		if len(step.Conclusion) > 0 {
+			status = step.Conclusion
+		}
+
+		duration := ""
+		if step.StartedAt != nil && !step.StartedAt.IsZero() {
+			duration = formatSeconds(step.DurationSeconds)
+		}
+
+		running := step.Status == "in_progress"
+
+		previous, diff := "", ""
+		if step.PreviousSeconds != nil {
+			previous = formatSeconds(*step.PreviousSeconds)
+			if len(duration) > 0 {
+				diff = formatStepDiff(step.DurationSeconds, *step.PreviousSeconds, running)
+			}
+		}
+
+		row := []string{strconv.FormatInt(step.Number, 10), step.Name, status, duration, previous, diff}
+		if running {
+			row = jobStarted.highlight(row)
+		}
+		rows = append(rows, row)
+	}
+
+	renderTable(w, []string{"#", "Step", "Status", "Duration", "Previous", "Diff"}, rows)
+}
+
+func formatSeconds(seconds float64) string {
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+// formatStepDiff shows how much slower or faster a step was than in the
+// previous run, coloured with the same thresholds as the OVERRUN column.
+func formatStepDiff(seconds, previous float64, highlighted bool) string {
+	diff := seconds - previous
+	if diff == 0 {
+		return ""
+	}
+	if diff < 0 {
+		return "-" + formatSeconds(-diff)
+	}
+
+	v := "+" + formatSeconds(diff)
+
+	// Ignore a few seconds of noise for the shortest steps
+	if previous <= 0 || diff < 10 {
+		return v
+	}
+
+	return thresholdColour(v, diff/previous*100, overrunWarning, overrunCritical, highlighted)
+}
+
+// stepRecords returns a row per step for CSV output.
+func stepRecords(steps []stepDescription) [][]string {
+	rows := [][]string{}
+	for _, step := range steps {
+		previous := ""
+		if step.PreviousSeconds != nil {
+			previous = formatValue(*step.PreviousSeconds)
+		}
+		rows = append(rows, []string{
+			strconv.FormatInt(step.Number, 10),
+			step.Name,
+			step.Status,
+			step.Conclusion,
+			formatTime(step.StartedAt),
+			formatTime(step.CompletedAt),
+			formatValue(step.DurationSeconds),
+			previous,
+		})
+	}
+	return rows
+}
+
+// jobSteps looks up the executing step of each running job with the GitHub
+// API, a few at a time, for the STEP column.
+func jobSteps(cmd *cobra.Command, client *github.Client, statuses []pkg.JobStatus) map[int64]string {
+	ctx := cmd.Context()
+	now := time.Now()
+
+	steps := map[int64]string{}
+	mu := sync.Mutex{}
+
+	sem := make(chan struct{}, reconcileConcurrency)
+	wg := sync.WaitGroup{}
+
+	for _, status := range statuses {
+		if status.Status != "in_progress" {
+			continue
+		}
+
+		wg.Add(1)
+		go func(status pkg.JobStatus) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			// The column is left empty when the job can't be looked up
+			job, _, err := client.Actions.GetWorkflowJobByID(ctx, status.Owner, status.Repo, status.JobID)
+			if err != nil {
+				return
+			}
+
+			step := currentStep(job)
+			if step == nil {
+				return
+			}
+
+			v := fmt.Sprintf("%d/%d %s", step.GetNumber(), len(job.Steps), step.GetName())
+			if duration, ok := stepDuration(step, now); ok {
+				v += "\n" + duration.Round(time.Second).String()
+			}
+
+			mu.Lock()
+			steps[status.JobID] = v
+			mu.Unlock()
+		}(status)
+	}
+
+	wg.Wait()
+
+	return steps
+}
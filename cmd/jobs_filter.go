@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/self-actuated/actuated-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+// jobSortKeys can be given to --sort-by on the commands which list jobs
+var jobSortKeys = []string{"queued", "started", "eta", "repo"}
+
+// jobFilter selects jobs from the build queue on the client, after they
+// have been fetched for an owner.
+type jobFilter struct {
+	Repos             []string
+	Workflow          string
+	Status            string
+	Labels            []string
+	Actor             string
+	Runner            string
+	Server            string
+	QueuedLongerThan  time.Duration
+	RunningLongerThan time.Duration
+
+	SortBy string
+	Limit  int
+}
+
+// addJobFilterFlags adds the filter flags to a command which applies them,
+// they are not inherited, so that sub-commands which do not filter jobs
+// reject them.
+func addJobFilterFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+
+	flags.StringSlice("repo", nil, "Only show jobs for a repo, as REPO or OWNER/REPO, wildcards such as \"api-*\" are allowed")
+	flags.String("workflow", "", "Only show jobs for a workflow, by its name, wildcards such as \"release-*\" are allowed")
+	flags.String("status", "", "Only show jobs with a status of queued or in_progress")
+	flags.StringSlice("label", nil, "Only show jobs with a label, can be given more than once")
+	flags.String("actor", "", "Only show jobs triggered by a GitHub user")
+	flags.String("runner", "", "Only show jobs on a runner")
+	flags.String("server", "", "Only show jobs on a server running the agent")
+	flags.Duration("queued-longer-than", 0, "Only show jobs which have been queued for longer than a duration i.e. 10m")
+	flags.Duration("running-longer-than", 0, "Only show jobs which have been running for longer than a duration i.e. 1h")
+}
+
+// addJobSortFlags adds --sort-by and --limit to a command which lists jobs,
+// they are not inherited by sub-commands such as cancel, where a limit would
+// silently narrow the selection.
+func addJobSortFlags(cmd *cobra.Command) {
+	cmd.Flags().String("sort-by", "", "Sort jobs by: "+strings.Join(jobSortKeys, ", "))
+	cmd.Flags().Int("limit", 0, "Show at most this many jobs, 0 for no limit")
+}
+
+// getJobFilter returns the filter given by the flags from addJobFilterFlags,
+// and addJobSortFlags when the command has them. The filter is empty for a
+// command without filter flags.
+func getJobFilter(cmd *cobra.Command) (jobFilter, error) {
+	f := jobFilter{}
+	flags := cmd.Flags()
+	if flags.Lookup("repo") == nil {
+		return f, nil
+	}
+
+	var err error
+	if f.Repos, err = flags.GetStringSlice("repo"); err != nil {
+		return f, err
+	}
+	if f.Workflow, err = flags.GetString("workflow"); err != nil {
+		return f, err
+	}
+	if f.Status, err = flags.GetString("status"); err != nil {
+		return f, err
+	}
+	if f.Labels, err = flags.GetStringSlice("label"); err != nil {
+		return f, err
+	}
+	if f.Actor, err = flags.GetString("actor"); err != nil {
+		return f, err
+	}
+	if f.Runner, err = flags.GetString("runner"); err != nil {
+		return f, err
+	}
+	if f.Server, err = flags.GetString("server"); err != nil {
+		return f, err
+	}
+	if f.QueuedLongerThan, err = flags.GetDuration("queued-longer-than"); err != nil {
+		return f, err
+	}
+	if f.RunningLongerThan, err = flags.GetDuration("running-longer-than"); err != nil {
+		return f, err
+	}
+	if flags.Lookup("sort-by") != nil {
+		if f.SortBy, err = flags.GetString("sort-by"); err != nil {
+			return f, err
+		}
+		if f.Limit, err = flags.GetInt("limit"); err != nil {
+			return f, err
+		}
+	}
+
+	if len(f.Status) > 0 && f.Status != "queued" && f.Status != "in_progress" {
+		return f, fmt.Errorf("--status must be queued or in_progress")
+	}
+
+	if len(f.SortBy) > 0 && !slices.Contains(jobSortKeys, f.SortBy) {
+		return f, fmt.Errorf("--sort-by must be one of: %s", strings.Join(jobSortKeys, ", "))
+	}
+
+	if f.Limit < 0 {
+		return f, fmt.Errorf("--limit must be 0 or greater")
+	}
+
+	for _, repo := range f.Repos {
+		if _, err := path.Match(strings.ToLower(repo), ""); err != nil {
+			return f, fmt.Errorf("invalid --repo: %q: %w", repo, err)
+		}
+	}
+
+	return f, nil
+}
+
+// apply returns the jobs which match the filter, sorted and limited.
+func (f jobFilter) apply(statuses []pkg.JobStatus, now time.Time) []pkg.JobStatus {
+	matched := []pkg.JobStatus{}
+	for _, status := range statuses {
+		if f.matches(status, now) {
+			matched = append(matched, status)
+		}
+	}
+
+	f.sort(matched, now)
+
+	if f.Limit > 0 && len(matched) > f.Limit {
+		matched = matched[:f.Limit]
+	}
+
+	return matched
+}
+
+func (f jobFilter) matches(status pkg.JobStatus, now time.Time) bool {
+	if len(f.Repos) > 0 {
+		found := false
+		for _, repo := range f.Repos {
+			if matchPattern(repo, status.Repo) || matchPattern(repo, status.Owner+"/"+status.Repo) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(f.Workflow) > 0 && !matchPattern(f.Workflow, status.WorkflowName) {
+		return false
+	}
+
+	if len(f.Status) > 0 && status.Status != f.Status {
+		return false
+	}
+
+	for _, label := range f.Labels {
+		if !slices.ContainsFunc(status.Labels, func(l string) bool {
+			return strings.EqualFold(l, label)
+		}) {
+			return false
+		}
+	}
+
+	if len(f.Actor) > 0 && !matchPattern(f.Actor, status.Actor) {
+		return false
+	}
+
+	if len(f.Runner) > 0 && !matchPattern(f.Runner, status.RunnerName) {
+		return false
+	}
+
+	if len(f.Server) > 0 && !matchPattern(f.Server, status.AgentName) {
+		return false
+	}
+
+	if f.QueuedLongerThan > 0 {
+		queuedAt := jobQueuedAt(status)
+		if status.Status != "queued" || queuedAt == nil || now.Sub(*queuedAt) <= f.QueuedLongerThan {
+			return false
+		}
+	}
+
+	if f.RunningLongerThan > 0 {
+		if status.Status != "in_progress" || status.StartedAt == nil || now.Sub(*status.StartedAt) <= f.RunningLongerThan {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sort orders the jobs by SortBy, jobs without a time for the key are
+// placed last.
+func (f jobFilter) sort(statuses []pkg.JobStatus, now time.Time) {
+	byTime := func(t func(pkg.JobStatus) *time.Time) func(i, j int) bool {
+		return func(i, j int) bool {
+			a, b := t(statuses[i]), t(statuses[j])
+			if a == nil || b == nil {
+				return a != nil
+			}
+			return a.Before(*b)
+		}
+	}
+
+	switch f.SortBy {
+	case "queued":
+		sort.SliceStable(statuses, byTime(jobQueuedAt))
+	case "started":
+		sort.SliceStable(statuses, byTime(func(s pkg.JobStatus) *time.Time {
+			return s.StartedAt
+		}))
+	case "eta":
+		sort.SliceStable(statuses, func(i, j int) bool {
+			a, aOk := jobETA(statuses[i], now)
+			b, bOk := jobETA(statuses[j], now)
+			if !aOk || !bOk {
+				return aOk
+			}
+			return a < b
+		})
+	case "repo":
+		sort.SliceStable(statuses, func(i, j int) bool {
+			a := statuses[i].Owner + "/" + statuses[i].Repo
+			b := statuses[j].Owner + "/" + statuses[j].Repo
+			if a != b {
+				return a < b
+			}
+			return statuses[i].JobName < statuses[j].JobName
+		})
+	}
+}
+
+// jobQueuedAt returns when the job was queued, older versions of the API
+// only give the time the job was last updated, which is only the time it
+// was queued whilst it is still queued.
+func jobQueuedAt(status pkg.JobStatus) *time.Time {
+	if status.QueuedAt != nil {
+		return status.QueuedAt
+	}
+	if status.Status == "queued" {
+		return status.UpdatedAt
+	}
+	return nil
+}
+
+// jobETA returns the time remaining for a running job, based upon its
+// average runtime, ok is false when there is no estimate.
+func jobETA(status pkg.JobStatus, now time.Time) (time.Duration, bool) {
+	if status.Status == "queued" || status.StartedAt == nil || status.AverageRuntime <= 0 {
+		return 0, false
+	}
+	return status.AverageRuntime - now.Sub(*status.StartedAt), true
+}
+
+// matchPattern matches a value without case, the pattern may contain
+// wildcards such as "*".
+func matchPattern(pattern, value string) bool {
+	ok, err := path.Match(strings.ToLower(pattern), strings.ToLower(value))
+	return err == nil && ok
+}
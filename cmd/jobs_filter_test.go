@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/self-actuated/actuated-cli/pkg"
+)
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		value   string
+		want    bool
+	}{
+		{"api", "api", true},
+		{"API", "api", true},
+		{"api", "api-server", false},
+		{"api-*", "api-server", true},
+		{"api-*", "web-api", false},
+		{"*", "", true},
+		{"self-actuated/*", "self-actuated/api", true},
+		{"*", "self-actuated/api", false},
+		{"job-?", "job-1", true},
+		{"job-?", "job-10", false},
+		{"job-[12]", "job-2", true},
+		{"job-[12]", "job-3", false},
+		{"job-[", "job-[", false},
+	}
+
+	for _, tc := range tests {
+		if got := matchPattern(tc.pattern, tc.value); got != tc.want {
+			t.Errorf("matchPattern(%q, %q): want %v, got %v", tc.pattern, tc.value, tc.want, got)
+		}
+	}
+}
+
+func TestJobFilterMatches(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tenMinsAgo := now.Add(-10 * time.Minute)
+
+	queued := pkg.JobStatus{
+		Owner:        "self-actuated",
+		Repo:         "api-server",
+		WorkflowName: "release-arm64",
+		Status:       "queued",
+		Labels:       []string{"actuated", "arm64"},
+		Actor:        "alexellis",
+		QueuedAt:     &tenMinsAgo,
+	}
+
+	running := pkg.JobStatus{
+		Owner:        "self-actuated",
+		Repo:         "web",
+		WorkflowName: "build",
+		Status:       "in_progress",
+		Labels:       []string{"actuated"},
+		RunnerName:   "fierce-swan",
+		AgentName:    "server-1",
+		StartedAt:    &tenMinsAgo,
+	}
+
+	tests := []struct {
+		name   string
+		filter jobFilter
+		status pkg.JobStatus
+		want   bool
+	}{
+		{"empty filter", jobFilter{}, queued, true},
+		{"repo", jobFilter{Repos: []string{"api-server"}}, queued, true},
+		{"repo wildcard", jobFilter{Repos: []string{"api-*"}}, queued, true},
+		{"owner and repo wildcard", jobFilter{Repos: []string{"self-actuated/api-*"}}, queued, true},
+		{"any of several repos", jobFilter{Repos: []string{"web", "api-*"}}, queued, true},
+		{"repo does not match", jobFilter{Repos: []string{"web"}}, queued, false},
+		{"owner does not match", jobFilter{Repos: []string{"other/*"}}, queued, false},
+		{"workflow wildcard", jobFilter{Workflow: "release-*"}, queued, true},
+		{"workflow does not match", jobFilter{Workflow: "release-*"}, running, false},
+		{"status", jobFilter{Status: "in_progress"}, running, true},
+		{"status does not match", jobFilter{Status: "queued"}, running, false},
+		{"all labels without case", jobFilter{Labels: []string{"ARM64", "actuated"}}, queued, true},
+		{"missing label", jobFilter{Labels: []string{"arm64"}}, running, false},
+		{"actor", jobFilter{Actor: "alex*"}, queued, true},
+		{"runner", jobFilter{Runner: "fierce-*"}, running, true},
+		{"server", jobFilter{Server: "server-?"}, running, true},
+		{"server does not match", jobFilter{Server: "server-2"}, running, false},
+		{"queued longer than", jobFilter{QueuedLongerThan: 5 * time.Minute}, queued, true},
+		{"queued shorter than", jobFilter{QueuedLongerThan: 15 * time.Minute}, queued, false},
+		{"queued longer than for a running job", jobFilter{QueuedLongerThan: 5 * time.Minute}, running, false},
+		{"running longer than", jobFilter{RunningLongerThan: 5 * time.Minute}, running, true},
+		{"running shorter than", jobFilter{RunningLongerThan: 15 * time.Minute}, running, false},
+		{"running longer than for a queued job", jobFilter{RunningLongerThan: 5 * time.Minute}, queued, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.filter.matches(tc.status, now); got != tc.want {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
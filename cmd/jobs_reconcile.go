@@ -0,0 +1,389 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v76/github"
+	"github.com/self-actuated/actuated-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+// reconcileConcurrency limits the requests made to the GitHub API at once
+const reconcileConcurrency = 4
+
+func makeJobsReconcile() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reconcile [OWNER]",
+		Short: "Compare jobs in the build queue with their state on GitHub",
+		Long: `Look up each job from the build queue with the GitHub Actions API, and
+report the jobs which GitHub shows as completed or cancelled, but which are
+still queued or in_progress in actuated.
+
+GitHub's API is often inconsistent, and can leave jobs showing as in_progress
+for days after they finished. Attach the file written by --report to a
+support request so that the jobs can be hidden.
+
+To look up jobs in private repositories, the token needs the "repo" scope,
+i.e. set GH_TOKEN to a token with access to the repositories.`,
+		Example: `  # Show jobs which have finished on GitHub
+  actuated-cli jobs reconcile
+
+  # Write a report for a support request
+  actuated-cli jobs reconcile --report reconcile.md
+
+  # Show the state on GitHub of every job, in JSON format
+  actuated-cli jobs reconcile --all -o json
+`,
+		Args: cobra.MaximumNArgs(1),
+	}
+
+	cmd.RunE = runJobsReconcileE
+
+	cmd.Flags().Bool("all", false, "Show every job, not just those which do not match GitHub")
+	cmd.Flags().String("report", "", "Write a report of the mismatched jobs to a file in Markdown")
+	addOutputFlags(cmd)
+	addJobFilterFlags(cmd)
+	addJobSortFlags(cmd)
+
+	return cmd
+}
+
+// reconcileResult compares the state of a job in actuated to GitHub.
+type reconcileResult struct {
+	JobID        int64  `json:"job_id"`
+	Owner        string `json:"owner"`
+	Repo         string `json:"repo"`
+	WorkflowName string `json:"workflow_name"`
+	JobName      string `json:"job_name"`
+	URL          string `json:"url"`
+
+	ActuatedStatus   string     `json:"actuatedStatus"`
+	GitHubStatus     string     `json:"githubStatus,omitempty"`
+	GitHubConclusion string     `json:"githubConclusion,omitempty"`
+	CompletedAt      *time.Time `json:"completedAt,omitempty"`
+
+	// Mismatch is set when the job has finished on GitHub, or is queued
+	// on one side and running on the other
+	Mismatch bool   `json:"mismatch"`
+	Error    string `json:"error,omitempty"`
+}
+
+func runJobsReconcileE(cmd *cobra.Command, args []string) error {
+
+	var owner string
+	if len(args) == 1 {
+		owner = strings.TrimSpace(args[0])
+	}
+	owner = getOwner(owner)
+
+	pat, err := getPat(cmd)
+	if err != nil {
+		return err
+	}
+
+	staff, err := getStaff(cmd)
+	if err != nil {
+		return err
+	}
+
+	output, err := getOutputFormat(cmd)
+	if err != nil {
+		return err
+	}
+
+	filter, err := getJobFilter(cmd)
+	if err != nil {
+		return err
+	}
+
+	all, err := cmd.Flags().GetBool("all")
+	if err != nil {
+		return err
+	}
+
+	reportFile, err := cmd.Flags().GetString("report")
+	if err != nil {
+		return err
+	}
+
+	if len(pat) == 0 {
+		return fmt.Errorf("pat is required")
+	}
+
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	statuses, err := c.ListJobs(cmd.Context(), pat, owner, staff)
+	if err != nil {
+		return err
+	}
+
+	statuses = filter.apply(statuses, time.Now())
+	for i := range statuses {
+		statuses[i].URL = jobURL(cmd, statuses[i])
+	}
+
+	ghClient, err := newGitHubClient(cmd, pat)
+	if err != nil {
+		return err
+	}
+
+	results, err := reconcileJobs(cmd, ghClient, statuses)
+	if err != nil {
+		return err
+	}
+
+	mismatched := []reconcileResult{}
+	failed := 0
+	for _, result := range results {
+		if result.Mismatch {
+			mismatched = append(mismatched, result)
+		}
+		if len(result.Error) > 0 {
+			failed++
+		}
+	}
+
+	if len(reportFile) > 0 {
+		if err := writeReconcileReport(reportFile, owner, len(results), mismatched); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Report written to: %s\n", reportFile)
+	}
+
+	shown := mismatched
+	if all {
+		shown = results
+	}
+
+	if err := printOutput(os.Stdout, output, shown,
+		func(w io.Writer, wide bool) error {
+			if len(shown) == 0 {
+				fmt.Fprintf(w, "All %d jobs match GitHub\n", len(results))
+				return nil
+			}
+			renderTable(w, reconcileHeader(wide), reconcileRows(shown, wide))
+			return nil
+		},
+		func() ([]string, [][]string) {
+			return reconcileHeader(true), reconcileRows(shown, true)
+		}); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d jobs could not be looked up on GitHub, run with --all to see why\n", failed)
+	}
+
+	return nil
+}
+
+// reconcileJobs looks up each job with the GitHub API, a few at a time.
+// Errors for a job are recorded in its result, apart from a rejected token,
+// which stops the lookups and is returned so that it can be renewed.
+func reconcileJobs(cmd *cobra.Command, client *github.Client, statuses []pkg.JobStatus) ([]reconcileResult, error) {
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+
+	results := make([]reconcileResult, len(statuses))
+
+	sem := make(chan struct{}, reconcileConcurrency)
+	wg := sync.WaitGroup{}
+
+	var (
+		mu      sync.Mutex
+		authErr error
+	)
+
+	for i, status := range statuses {
+		wg.Add(1)
+		go func(i int, status pkg.JobStatus) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result := reconcileResult{
+				JobID:          status.JobID,
+				Owner:          status.Owner,
+				Repo:           status.Repo,
+				WorkflowName:   status.WorkflowName,
+				JobName:        status.JobName,
+				URL:            status.URL,
+				ActuatedStatus: status.Status,
+			}
+
+			job, _, err := client.Actions.GetWorkflowJobByID(ctx, status.Owner, status.Repo, status.JobID)
+			if isUnauthorized(err) {
+				mu.Lock()
+				if authErr == nil {
+					authErr = err
+					cancel()
+				}
+				mu.Unlock()
+				return
+			} else if err != nil {
+				result.Error = reconcileError(err)
+			} else {
+				result.GitHubStatus = job.GetStatus()
+				result.GitHubConclusion = job.GetConclusion()
+				if job.CompletedAt != nil {
+					completedAt := job.CompletedAt.Time
+					result.CompletedAt = &completedAt
+				}
+				result.Mismatch = isMismatch(status.Status, result.GitHubStatus)
+			}
+
+			results[i] = result
+		}(i, status)
+	}
+
+	wg.Wait()
+
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	return results, nil
+}
+
+// isMismatch reports whether a job has finished on GitHub, or is queued on
+// one side and running on the other.
+func isMismatch(actuatedStatus, githubStatus string) bool {
+	switch githubStatus {
+	case "completed":
+		return true
+	case "in_progress":
+		return actuatedStatus == "queued"
+	case "queued", "waiting", "pending", "requested":
+		return actuatedStatus == "in_progress"
+	}
+	return false
+}
+
+// reconcileError explains why a job could not be found on GitHub.
+func reconcileError(err error) string {
+	var ghErr *github.ErrorResponse
+	if errors.As(err, &ghErr) && ghErr.Response != nil && ghErr.Response.StatusCode == http.StatusNotFound {
+		return "not found, the token may need the repo scope"
+	}
+	return err.Error()
+}
+
+func reconcileHeader(wide bool) []string {
+	header := []string{"Job ID", "Repo", "Job", "Actuated", "GitHub", "Completed"}
+	if wide {
+		header = append(header, "URL", "Error")
+	}
+	return header
+}
+
+func reconcileRows(results []reconcileResult, wide bool) [][]string {
+	rows := [][]string{}
+
+	for _, result := range results {
+		github := result.GitHubStatus
+		if len(result.GitHubConclusion) > 0 {
+			github += " (" + result.GitHubConclusion + ")"
+		}
+		if len(result.Error) > 0 && !wide {
+			github = "error"
+		}
+
+		completed := ""
+		if result.CompletedAt != nil {
+			completed = time.Since(*result.CompletedAt).Round(time.Minute).String() + " ago"
+		}
+
+		job := result.JobName
+		if len(result.WorkflowName) > 0 {
+			job = result.WorkflowName + "/" + job
+		}
+
+		row := []string{
+			strconv.FormatInt(result.JobID, 10),
+			result.Owner + "/" + result.Repo,
+			job,
+			result.ActuatedStatus,
+			github,
+			completed,
+		}
+		if wide {
+			row = append(row, result.URL, result.Error)
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// writeReconcileReport writes the mismatched jobs as Markdown, to attach to
+// a support request.
+func writeReconcileReport(filePath, owner string, checked int, mismatched []reconcileResult) error {
+	version := pkg.Version
+	if len(version) == 0 {
+		version = "dev"
+	}
+
+	if len(owner) == 0 {
+		owner = "all authorized organisations"
+	}
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "# Jobs which do not match GitHub\n\n")
+	fmt.Fprintf(buf, "* Generated: %s\n", time.Now().UTC().Format(time.RFC3339))
+	fmt.Fprintf(buf, "* Owner: %s\n", owner)
+	fmt.Fprintf(buf, "* actuated-cli version: %s\n", version)
+	fmt.Fprintf(buf, "* Jobs checked: %d, mismatched: %d\n\n", checked, len(mismatched))
+
+	if len(mismatched) > 0 {
+		rows := [][]string{}
+		for _, result := range mismatched {
+			completedAt := ""
+			if result.CompletedAt != nil {
+				completedAt = result.CompletedAt.UTC().Format(time.RFC3339)
+			}
+			rows = append(rows, []string{
+				strconv.FormatInt(result.JobID, 10),
+				result.Owner + "/" + result.Repo,
+				result.ActuatedStatus,
+				strings.TrimSpace(result.GitHubStatus + " " + result.GitHubConclusion),
+				completedAt,
+				result.URL,
+			})
+		}
+		renderTable(buf, []string{"Job ID", "Repo", "Actuated", "GitHub", "Completed at", "URL"}, rows)
+	}
+
+	// The report lists private repos and jobs, so it is only readable by
+	// the user. The mode given to OpenFile only applies to a new file, so
+	// an existing report is changed before anything is written to it.
+	f, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
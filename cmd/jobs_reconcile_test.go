@@ -0,0 +1,30 @@
+package cmd
+
+import "testing"
+
+func TestIsMismatch(t *testing.T) {
+	tests := []struct {
+		actuated string
+		github   string
+		want     bool
+	}{
+		{"in_progress", "completed", true},
+		{"queued", "completed", true},
+		{"queued", "in_progress", true},
+		{"in_progress", "in_progress", false},
+		{"in_progress", "queued", true},
+		{"in_progress", "waiting", true},
+		{"in_progress", "pending", true},
+		{"in_progress", "requested", true},
+		{"queued", "queued", false},
+		{"queued", "waiting", false},
+		{"queued", "", false},
+		{"in_progress", "unknown", false},
+	}
+
+	for _, tc := range tests {
+		if got := isMismatch(tc.actuated, tc.github); got != tc.want {
+			t.Errorf("isMismatch(%q, %q): want %v, got %v", tc.actuated, tc.github, tc.want, got)
+		}
+	}
+}
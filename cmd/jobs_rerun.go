@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/google/go-github/v76/github"
+	"github.com/self-actuated/actuated-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+func makeJobsRerun() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rerun [JOB_ID|URL...]",
+		Short: "Re-run jobs on GitHub",
+		Long: `Re-run jobs given by their ID or URL, or select the jobs which did not
+succeed in the recent completed workflow runs of a repo, given with
+--repo OWNER/REPO. The jobs which depend on a job are re-run along with it.
+
+GitHub can only re-run a job once its workflow run has completed, so cancel
+a hung job first with "actuated-cli jobs cancel". A job ID which is no longer
+in the build queue can be given along with --repo OWNER/REPO.
+
+Completed jobs can be narrowed down with --workflow, --label, --actor and
+--runner.
+
+Use --failed-only to re-run all the failed jobs in the workflow run of each
+job instead. GitHub will not re-run jobs of the same workflow run one by
+one, so when several jobs are selected from one run, the failed jobs of
+that run are re-run together.
+
+Jobs selected with filters are listed, then you will be asked to confirm,
+use --yes to skip the prompt.`,
+		Example: `  # Re-run a job by its URL
+  actuated-cli jobs rerun https://github.com/acme/api/actions/runs/1234/job/5678
+
+  # Re-run a job by its ID, after it was cancelled
+  actuated-cli jobs rerun 5678 --repo acme/api
+
+  # Re-run the failed jobs in the workflow run of a job
+  actuated-cli jobs rerun --failed-only https://github.com/acme/api/runs/5678
+
+  # Re-run the jobs which did not succeed in the recent runs of a workflow
+  actuated-cli jobs rerun --repo acme/api --workflow ci
+`,
+	}
+
+	cmd.RunE = runJobsRerunE
+
+	cmd.Flags().Bool("failed-only", false, "Re-run the failed jobs in the workflow run of each job")
+	cmd.Flags().BoolP("yes", "y", false, "Re-run jobs selected with filters without asking for confirmation")
+	addJobFilterFlags(cmd)
+
+	return cmd
+}
+
+func runJobsRerunE(cmd *cobra.Command, args []string) error {
+	failedOnly, err := cmd.Flags().GetBool("failed-only")
+	if err != nil {
+		return err
+	}
+
+	jobs, bulk, err := selectCompletedJobs(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	if len(jobs) == 0 {
+		fmt.Println("No jobs matched the filters")
+		return nil
+	}
+
+	if bulk {
+		ok, err := confirmJobs(cmd, "re-run", jobs)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("aborted")
+		}
+	}
+
+	pat, err := getPat(cmd)
+	if err != nil {
+		return err
+	}
+
+	ghClient, err := newGitHubClient(cmd, pat)
+	if err != nil {
+		return err
+	}
+
+	// A re-run starts a new attempt of the workflow run, after which GitHub
+	// will not re-run the other jobs of the run on their own, so several
+	// jobs in a run are re-run together with the failed jobs of the run
+	groups, failed := groupByRun(cmd, ghClient, jobs)
+
+	for _, group := range groups {
+		job := group[0]
+
+		if !failedOnly && len(group) == 1 {
+			if _, err := ghClient.Actions.RerunJobByID(cmd.Context(), job.Owner, job.Repo, job.JobID); err != nil && !isAccepted(err) {
+				fmt.Fprintf(os.Stderr, "unable to re-run %s: %s\n", job, err)
+				failed++
+				continue
+			}
+
+			fmt.Printf("Re-running %s\n", job)
+			continue
+		}
+
+		if _, err := ghClient.Actions.RerunFailedJobsByID(cmd.Context(), job.Owner, job.Repo, job.RunID); err != nil && !isAccepted(err) {
+			fmt.Fprintf(os.Stderr, "unable to re-run the failed jobs in workflow run %d for %s: %s\n", job.RunID, job, err)
+			failed += len(group)
+			continue
+		}
+
+		for _, job := range group {
+			fmt.Printf("Re-running the failed jobs in workflow run %d for %s\n", job.RunID, job)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d jobs could not be re-run", failed, len(jobs))
+	}
+
+	return nil
+}
+
+// rerunRunsLimit is the number of recent completed workflow runs to search
+// for jobs to re-run
+const rerunRunsLimit = 20
+
+// rerunConclusions are the conclusions of jobs which are selected by filters
+// to be re-run
+var rerunConclusions = []string{"failure", "cancelled", "timed_out", "startup_failure"}
+
+// selectCompletedJobs returns the jobs given as IDs or URLs in args, or when
+// there are no args, the jobs which did not succeed in the recent completed
+// workflow runs of the repo given with --repo, since jobs in the build queue
+// cannot be re-run. bulk is true when the jobs were selected by the filters.
+func selectCompletedJobs(cmd *cobra.Command, args []string) (jobs []jobRef, bulk bool, err error) {
+	filter, err := getJobFilter(cmd)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(args) > 0 {
+		jobs, err := resolveJobs(cmd, args, filter)
+		return jobs, false, err
+	}
+
+	owner, repo, ok := filter.exactRepo()
+	if !ok {
+		return nil, false, fmt.Errorf("give a job ID or URL, or --repo as OWNER/REPO to select completed jobs")
+	}
+
+	if len(filter.Status) > 0 || len(filter.Server) > 0 || filter.QueuedLongerThan > 0 || filter.RunningLongerThan > 0 {
+		return nil, false, fmt.Errorf("--status, --server, --queued-longer-than and --running-longer-than only apply to jobs in the build queue")
+	}
+
+	pat, err := getPat(cmd)
+	if err != nil {
+		return nil, false, err
+	}
+
+	ghClient, err := newGitHubClient(cmd, pat)
+	if err != nil {
+		return nil, false, err
+	}
+
+	runs, _, err := ghClient.Actions.ListRepositoryWorkflowRuns(cmd.Context(), owner, repo, &github.ListWorkflowRunsOptions{
+		Status:      "completed",
+		ListOptions: github.ListOptions{PerPage: rerunRunsLimit},
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to list the workflow runs for %s/%s: %w", owner, repo, err)
+	}
+
+	now := time.Now()
+	for _, run := range runs.WorkflowRuns {
+		if run.GetConclusion() == "success" {
+			continue
+		}
+
+		runJobs, err := listWorkflowJobs(cmd, ghClient, owner, repo, run.GetID())
+		if err != nil {
+			return nil, false, err
+		}
+
+		for _, job := range runJobs {
+			if !slices.Contains(rerunConclusions, job.GetConclusion()) {
+				continue
+			}
+
+			status := pkg.JobStatus{
+				JobID:        job.GetID(),
+				Owner:        owner,
+				Repo:         repo,
+				WorkflowName: job.GetWorkflowName(),
+				JobName:      job.GetName(),
+				Actor:        run.GetActor().GetLogin(),
+				Labels:       job.Labels,
+				RunnerName:   job.GetRunnerName(),
+				Status:       job.GetStatus(),
+				Conclusion:   job.GetConclusion(),
+			}
+			if !filter.matches(status, now) {
+				continue
+			}
+
+			jobs = append(jobs, jobRef{Owner: owner, Repo: repo, JobID: status.JobID, RunID: run.GetID(), Name: jobName(status)})
+		}
+	}
+
+	return jobs, true, nil
+}
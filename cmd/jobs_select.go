@@ -0,0 +1,343 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v76/github"
+	"github.com/self-actuated/actuated-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+// jobRef is a workflow job on GitHub, given as an argument or selected
+// from the build queue.
+type jobRef struct {
+	Owner string
+	Repo  string
+	JobID int64
+
+	// RunID is the workflow run of the job, 0 when it is not yet known
+	RunID int64
+
+	// Name is the workflow and job name, when the job was found in the
+	// queue or on GitHub
+	Name string
+}
+
+func (j jobRef) String() string {
+	s := fmt.Sprintf("%s/%s job %d", j.Owner, j.Repo, j.JobID)
+	if len(j.Name) > 0 {
+		s += " (" + j.Name + ")"
+	}
+	return s
+}
+
+// parseJobURL parses the URL of a job, as shown by "actuated-cli jobs -v":
+//
+//	https://github.com/OWNER/REPO/runs/JOB_ID
+//
+// or as shown in the browser, optionally for an attempt of the run:
+//
+//	https://github.com/OWNER/REPO/actions/runs/RUN_ID/job/JOB_ID
+//	https://github.com/OWNER/REPO/actions/runs/RUN_ID/attempts/N/job/JOB_ID
+func parseJobURL(s string) (jobRef, error) {
+	u, err := url.Parse(s)
+	if err != nil || len(u.Host) == 0 {
+		return jobRef{}, fmt.Errorf("invalid job URL: %q", s)
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+
+	// The job ID is the same for every attempt of a run
+	if len(parts) >= 9 && parts[5] == "attempts" {
+		if _, err := strconv.Atoi(parts[6]); err != nil {
+			return jobRef{}, fmt.Errorf("invalid job URL: %q: attempt %q is not a number", s, parts[6])
+		}
+		parts = append(parts[:5:5], parts[7:]...)
+	}
+
+	var ref jobRef
+	switch {
+	case len(parts) == 4 && parts[2] == "runs":
+		ref.JobID, err = strconv.ParseInt(parts[3], 10, 64)
+	case len(parts) >= 7 && parts[2] == "actions" && parts[3] == "runs" && parts[5] == "job":
+		if ref.RunID, err = strconv.ParseInt(parts[4], 10, 64); err == nil {
+			ref.JobID, err = strconv.ParseInt(parts[6], 10, 64)
+		}
+	default:
+		return jobRef{}, fmt.Errorf("invalid job URL: %q, expected https://github.com/OWNER/REPO/actions/runs/RUN_ID/job/JOB_ID", s)
+	}
+	if err != nil {
+		return jobRef{}, fmt.Errorf("invalid job URL: %q: %w", s, err)
+	}
+
+	ref.Owner = parts[0]
+	ref.Repo = parts[1]
+
+	return ref, nil
+}
+
+// hasSelection reports whether any of the filters which select jobs were
+// given, sorting and a limit alone do not select any jobs.
+func (f jobFilter) hasSelection() bool {
+	return len(f.Repos) > 0 || len(f.Workflow) > 0 || len(f.Status) > 0 || len(f.Labels) > 0 ||
+		len(f.Actor) > 0 || len(f.Runner) > 0 || len(f.Server) > 0 ||
+		f.QueuedLongerThan > 0 || f.RunningLongerThan > 0
+}
+
+// exactRepo returns the owner and repo when --repo was given once as
+// OWNER/REPO, without wildcards, so that jobs can be looked up on GitHub.
+func (f jobFilter) exactRepo() (owner, repo string, ok bool) {
+	if len(f.Repos) != 1 || strings.ContainsAny(f.Repos[0], "*?[") {
+		return "", "", false
+	}
+
+	owner, repo, ok = strings.Cut(f.Repos[0], "/")
+	if !ok || len(owner) == 0 || len(repo) == 0 || strings.Contains(repo, "/") {
+		return "", "", false
+	}
+	return owner, repo, true
+}
+
+// selectJobs returns the jobs given as IDs or URLs in args, or when there
+// are no args, the jobs in the build queue which match the filters. bulk is
+// true when the jobs were selected by the filters.
+func selectJobs(cmd *cobra.Command, args []string) (jobs []jobRef, bulk bool, err error) {
+	filter, err := getJobFilter(cmd)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(args) > 0 {
+		jobs, err := resolveJobs(cmd, args, filter)
+		return jobs, false, err
+	}
+
+	if !filter.hasSelection() {
+		return nil, false, fmt.Errorf("give a job ID or URL, or filters such as --repo to select jobs from the queue")
+	}
+
+	statuses, err := listQueue(cmd)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, status := range filter.apply(statuses, time.Now()) {
+		jobs = append(jobs, jobRef{Owner: status.Owner, Repo: status.Repo, JobID: status.JobID, Name: jobName(status)})
+	}
+
+	return jobs, true, nil
+}
+
+// resolveJobs returns the jobs given as IDs or URLs. A job ID is found in the
+// build queue, or once it has left the queue, on GitHub within the repo given
+// with --repo OWNER/REPO.
+func resolveJobs(cmd *cobra.Command, args []string, filter jobFilter) ([]jobRef, error) {
+	owner, repo, hasRepo := filter.exactRepo()
+
+	others := filter
+	others.Repos = nil
+	if others.hasSelection() {
+		return nil, fmt.Errorf("give either jobs as arguments, or filters such as --status, not both")
+	}
+	if len(filter.Repos) > 0 && !hasRepo {
+		return nil, fmt.Errorf("give --repo as OWNER/REPO, without wildcards, to look up jobs by their ID")
+	}
+
+	jobs := []jobRef{}
+	ids := []int64{}
+	for _, arg := range args {
+		arg = strings.TrimSpace(arg)
+		if strings.Contains(arg, "://") {
+			ref, err := parseJobURL(arg)
+			if err != nil {
+				return nil, err
+			}
+			jobs = append(jobs, ref)
+			continue
+		}
+
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid job: %q, give a job ID or URL", arg)
+		}
+		ids = append(ids, id)
+	}
+
+	// URLs give the owner and repo, but for job IDs they have to be found
+	// from the queue or GitHub
+	if len(ids) == 0 {
+		return jobs, nil
+	}
+
+	statuses, err := listQueue(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	var ghClient *github.Client
+	for _, id := range ids {
+		i := slices.IndexFunc(statuses, func(status pkg.JobStatus) bool {
+			return status.JobID == id
+		})
+		if i >= 0 {
+			status := statuses[i]
+			jobs = append(jobs, jobRef{Owner: status.Owner, Repo: status.Repo, JobID: status.JobID, Name: jobName(status)})
+			continue
+		}
+
+		if !hasRepo {
+			return nil, fmt.Errorf("job %d is not in the build queue, give its URL, or its repo with --repo OWNER/REPO", id)
+		}
+
+		if ghClient == nil {
+			pat, err := getPat(cmd)
+			if err != nil {
+				return nil, err
+			}
+			if ghClient, err = newGitHubClient(cmd, pat); err != nil {
+				return nil, err
+			}
+		}
+
+		ghJob, _, err := ghClient.Actions.GetWorkflowJobByID(cmd.Context(), owner, repo, id)
+		if err != nil {
+			return nil, fmt.Errorf("job %d is not in the build queue, and could not be found in %s/%s on GitHub: %w", id, owner, repo, err)
+		}
+
+		jobs = append(jobs, jobRef{
+			Owner: owner,
+			Repo:  repo,
+			JobID: id,
+			RunID: ghJob.GetRunID(),
+			Name:  jobName(pkg.JobStatus{WorkflowName: ghJob.GetWorkflowName(), JobName: ghJob.GetName()}),
+		})
+	}
+
+	return jobs, nil
+}
+
+// listQueue returns the jobs in the build queue for the owner.
+func listQueue(cmd *cobra.Command) ([]pkg.JobStatus, error) {
+	pat, err := getPat(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	staff, err := getStaff(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pat) == 0 {
+		return nil, fmt.Errorf("pat is required")
+	}
+
+	c, err := newClient(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.ListJobs(cmd.Context(), pat, getOwner(""), staff)
+}
+
+// confirmJobs lists the jobs, then asks for confirmation unless --yes was
+// given.
+func confirmJobs(cmd *cobra.Command, action string, jobs []jobRef) (bool, error) {
+	yes, err := cmd.Flags().GetBool("yes")
+	if err != nil {
+		return false, err
+	}
+
+	for _, job := range jobs {
+		fmt.Fprintf(os.Stderr, "  %s\n", job)
+	}
+
+	if yes {
+		return true, nil
+	}
+
+	if !isTerminal(os.Stdin) {
+		return false, fmt.Errorf("use --yes to %s jobs without a terminal", action)
+	}
+
+	return confirm(fmt.Sprintf("%s %d jobs? [y/N] ", strings.ToUpper(action[:1])+action[1:], len(jobs))), nil
+}
+
+// lookupRunID finds the workflow run of a job, for jobs given by ID.
+func lookupRunID(cmd *cobra.Command, client *github.Client, job *jobRef) error {
+	if job.RunID > 0 {
+		return nil
+	}
+
+	ghJob, _, err := client.Actions.GetWorkflowJobByID(cmd.Context(), job.Owner, job.Repo, job.JobID)
+	if err != nil {
+		return fmt.Errorf("unable to find %s on GitHub: %w", job, err)
+	}
+
+	job.RunID = ghJob.GetRunID()
+	return nil
+}
+
+// groupByRun looks up the workflow run of each job, then groups the jobs by
+// their run, in the order in which the runs were first seen. Jobs whose run
+// could not be found are reported to stderr and counted in failed.
+func groupByRun(cmd *cobra.Command, client *github.Client, jobs []jobRef) (runs [][]jobRef, failed int) {
+	index := map[string]int{}
+	for _, job := range jobs {
+		if err := lookupRunID(cmd, client, &job); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			failed++
+			continue
+		}
+
+		key := fmt.Sprintf("%s/%s/%d", job.Owner, job.Repo, job.RunID)
+		i, ok := index[key]
+		if !ok {
+			i = len(runs)
+			index[key] = i
+			runs = append(runs, nil)
+		}
+		runs[i] = append(runs[i], job)
+	}
+
+	return runs, failed
+}
+
+// listWorkflowJobs returns the jobs of the latest attempt of a workflow
+// run, a page at a time, since a matrix can have more jobs than fit on one.
+func listWorkflowJobs(cmd *cobra.Command, client *github.Client, owner, repo string, runID int64) ([]*github.WorkflowJob, error) {
+	opts := &github.ListWorkflowJobsOptions{
+		Filter:      "latest",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	jobs := []*github.WorkflowJob{}
+	for {
+		page, res, err := client.Actions.ListWorkflowJobs(cmd.Context(), owner, repo, runID, opts)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list the jobs in workflow run %d for %s/%s: %w", runID, owner, repo, err)
+		}
+
+		jobs = append(jobs, page.Jobs...)
+		if res.NextPage == 0 {
+			break
+		}
+		opts.Page = res.NextPage
+	}
+
+	return jobs, nil
+}
+
+// isAccepted reports whether err is a 202 Accepted response from GitHub,
+// which is returned for requests that are processed in the background.
+func isAccepted(err error) bool {
+	var accepted *github.AcceptedError
+	return errors.As(err, &accepted)
+}
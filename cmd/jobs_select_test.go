@@ -0,0 +1,55 @@
+package cmd
+
+import "testing"
+
+func TestParseJobURL(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want jobRef
+	}{
+		{"runs URL from jobs -v", "https://github.com/self-actuated/api/runs/123", jobRef{Owner: "self-actuated", Repo: "api", JobID: 123}},
+		{"browser URL", "https://github.com/self-actuated/api/actions/runs/45/job/123", jobRef{Owner: "self-actuated", Repo: "api", JobID: 123, RunID: 45}},
+		{"browser URL with a query", "https://github.com/self-actuated/api/actions/runs/45/job/123?pr=7", jobRef{Owner: "self-actuated", Repo: "api", JobID: 123, RunID: 45}},
+		{"trailing slash", "https://github.com/self-actuated/api/actions/runs/45/job/123/", jobRef{Owner: "self-actuated", Repo: "api", JobID: 123, RunID: 45}},
+		{"attempt of a run", "https://github.com/self-actuated/api/actions/runs/45/attempts/2/job/123", jobRef{Owner: "self-actuated", Repo: "api", JobID: 123, RunID: 45}},
+		{"Enterprise Server host", "https://github.example.com/acme/web/runs/9", jobRef{Owner: "acme", Repo: "web", JobID: 9}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseJobURL(tc.url)
+			if err != nil {
+				t.Fatalf("%q: %s", tc.url, err)
+			}
+
+			if got != tc.want {
+				t.Errorf("%q: want %+v, got %+v", tc.url, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestParseJobURLInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"job ID", "123"},
+		{"no host", "/self-actuated/api/runs/123"},
+		{"repo URL", "https://github.com/self-actuated/api"},
+		{"run without a job", "https://github.com/self-actuated/api/actions/runs/45"},
+		{"job ID is not a number", "https://github.com/self-actuated/api/runs/abc"},
+		{"run ID is not a number", "https://github.com/self-actuated/api/actions/runs/abc/job/123"},
+		{"attempt is not a number", "https://github.com/self-actuated/api/actions/runs/45/attempts/x/job/123"},
+		{"attempt without a job", "https://github.com/self-actuated/api/actions/runs/45/attempts/2"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if ref, err := parseJobURL(tc.url); err == nil {
+				t.Errorf("%q: want an error, got %+v", tc.url, ref)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/self-actuated/actuated-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+func makeJobsStats() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stats [OWNER]",
+		Short: "Summarise the jobs in the build queue",
+		Long: `Summarise the queued and in_progress jobs with counts by status, repo,
+labels and server, the age of the oldest queued job, the p50 and p95 time
+spent waiting in the queue, and the number of jobs running for longer than
+their average runtime.
+
+The filters from "actuated-cli jobs" can be used to summarise a subset of
+the jobs.`,
+		Example: `  # Summarise the build queue
+  actuated-cli jobs stats
+
+  # Summarise the jobs for repos starting with "api-" in JSON format
+  actuated-cli jobs stats --repo 'api-*' -o json
+`,
+		Args: cobra.MaximumNArgs(1),
+	}
+
+	cmd.RunE = runJobsStatsE
+
+	addOutputFlags(cmd)
+	addJobFilterFlags(cmd)
+
+	return cmd
+}
+
+// jobStats is a summary of the build queue, durations are in seconds.
+type jobStats struct {
+	Total int `json:"total"`
+
+	ByStatus map[string]int `json:"byStatus"`
+	ByRepo   map[string]int `json:"byRepo"`
+	ByLabels map[string]int `json:"byLabels"`
+	ByServer map[string]int `json:"byServer"`
+
+	OldestQueuedSeconds float64 `json:"oldestQueuedSeconds"`
+	WaitP50Seconds      float64 `json:"waitP50Seconds"`
+	WaitP95Seconds      float64 `json:"waitP95Seconds"`
+
+	// WaitSamples is the number of jobs in the wait percentiles, jobs
+	// without a time they were queued are left out
+	WaitSamples int `json:"waitSamples"`
+
+	// Overrunning is the number of jobs running for longer than their
+	// average runtime
+	Overrunning int `json:"overrunning"`
+}
+
+func runJobsStatsE(cmd *cobra.Command, args []string) error {
+
+	var owner string
+	if len(args) == 1 {
+		owner = strings.TrimSpace(args[0])
+	}
+	owner = getOwner(owner)
+
+	pat, err := getPat(cmd)
+	if err != nil {
+		return err
+	}
+
+	staff, err := getStaff(cmd)
+	if err != nil {
+		return err
+	}
+
+	output, err := getOutputFormat(cmd)
+	if err != nil {
+		return err
+	}
+
+	filter, err := getJobFilter(cmd)
+	if err != nil {
+		return err
+	}
+
+	if len(pat) == 0 {
+		return fmt.Errorf("pat is required")
+	}
+
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	statuses, err := c.ListJobs(cmd.Context(), pat, owner, staff)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	stats := summariseJobs(filter.apply(statuses, now), now)
+
+	return printOutput(os.Stdout, output, stats,
+		func(w io.Writer, wide bool) error {
+			printJobStats(w, stats)
+			return nil
+		},
+		func() ([]string, [][]string) {
+			return []string{"group", "name", "value"}, jobStatsRecords(stats)
+		})
+}
+
+// summariseJobs aggregates the jobs into counts, and the time spent queued.
+func summariseJobs(statuses []pkg.JobStatus, now time.Time) jobStats {
+	stats := jobStats{
+		Total:    len(statuses),
+		ByStatus: map[string]int{},
+		ByRepo:   map[string]int{},
+		ByLabels: map[string]int{},
+		ByServer: map[string]int{},
+	}
+
+	waits := []time.Duration{}
+	var oldest time.Duration
+
+	for _, status := range statuses {
+		stats.ByStatus[status.Status]++
+		stats.ByRepo[status.Owner+"/"+status.Repo]++
+
+		labels := slices.Clone(status.Labels)
+		sort.Strings(labels)
+		stats.ByLabels[strings.Join(labels, ",")]++
+
+		if len(status.AgentName) > 0 {
+			stats.ByServer[status.AgentName]++
+		}
+
+		// Jobs without a queue time are left out, rather than pulling
+		// the percentiles down
+		if wait, ok := jobWait(status, now); ok {
+			waits = append(waits, wait)
+			if status.Status == "queued" && wait > oldest {
+				oldest = wait
+			}
+		}
+
+		if _, ok := jobOverrun(status, now); ok {
+			stats.Overrunning++
+		}
+	}
+
+	slices.Sort(waits)
+
+	stats.WaitSamples = len(waits)
+	stats.OldestQueuedSeconds = math.Round(oldest.Seconds())
+	stats.WaitP50Seconds = math.Round(percentile(waits, 50).Seconds())
+	stats.WaitP95Seconds = math.Round(percentile(waits, 95).Seconds())
+
+	return stats
+}
+
+// percentile returns the nearest-rank percentile p of sorted values.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
+
+func printJobStats(w io.Writer, stats jobStats) {
+	seconds := func(v float64) string {
+		return (time.Duration(v) * time.Second).String()
+	}
+
+	fmt.Fprintf(w, "Jobs: %d, queued: %d, in_progress: %d, overrunning: %d\n",
+		stats.Total, stats.ByStatus["queued"], stats.ByStatus["in_progress"], stats.Overrunning)
+	fmt.Fprintf(w, "Oldest queued: %s, wait p50: %s, p95: %s (of %d jobs)\n\n",
+		seconds(stats.OldestQueuedSeconds), seconds(stats.WaitP50Seconds), seconds(stats.WaitP95Seconds), stats.WaitSamples)
+
+	renderTable(w, []string{"By", "Name", "Jobs"}, jobStatsCounts(stats))
+}
+
+// jobStatsRecords returns the summary, then the counts for CSV output.
+func jobStatsRecords(stats jobStats) [][]string {
+	rows := [][]string{
+		{"summary", "total", strconv.Itoa(stats.Total)},
+		{"summary", "overrunning", strconv.Itoa(stats.Overrunning)},
+		{"summary", "oldest_queued_seconds", formatValue(stats.OldestQueuedSeconds)},
+		{"summary", "wait_p50_seconds", formatValue(stats.WaitP50Seconds)},
+		{"summary", "wait_p95_seconds", formatValue(stats.WaitP95Seconds)},
+		{"summary", "wait_samples", strconv.Itoa(stats.WaitSamples)},
+	}
+
+	return append(rows, jobStatsCounts(stats)...)
+}
+
+// jobStatsCounts returns a row for each status, repo, label set and server
+// with the number of jobs, the largest counts are first within each group.
+func jobStatsCounts(stats jobStats) [][]string {
+	groups := []struct {
+		name   string
+		counts map[string]int
+	}{
+		{name: "status", counts: stats.ByStatus},
+		{name: "repo", counts: stats.ByRepo},
+		{name: "labels", counts: stats.ByLabels},
+		{name: "server", counts: stats.ByServer},
+	}
+
+	rows := [][]string{}
+	for _, group := range groups {
+		for _, count := range sortedCounts(group.counts) {
+			rows = append(rows, []string{group.name, count.name, strconv.Itoa(count.count)})
+		}
+	}
+
+	return rows
+}
+
+type namedCount struct {
+	name  string
+	count int
+}
+
+// sortedCounts returns the counts with the largest first.
+func sortedCounts(counts map[string]int) []namedCount {
+	sorted := make([]namedCount, 0, len(counts))
+	for name, count := range counts {
+		if len(name) == 0 {
+			name = "(none)"
+		}
+		sorted = append(sorted, namedCount{name: name, count: count})
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].count != sorted[j].count {
+			return sorted[i].count > sorted[j].count
+		}
+		return sorted[i].name < sorted[j].name
+	})
+
+	return sorted
+}
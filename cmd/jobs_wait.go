@@ -0,0 +1,394 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/google/go-github/v76/github"
+	"github.com/self-actuated/actuated-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes for "jobs wait", 1 is left for errors from the CLI
+const (
+	waitSuccess   = 0
+	waitFailure   = 2
+	waitCancelled = 3
+	waitTimedOut  = 4
+
+	// waitDeadline is when --wait-timeout was reached before the jobs completed
+	waitDeadline = 5
+)
+
+// waitConclusions maps a conclusion from GitHub to an exit code, any other
+// conclusion such as startup_failure is a failure.
+var waitConclusions = map[string]int{
+	"success":   waitSuccess,
+	"skipped":   waitSuccess,
+	"neutral":   waitSuccess,
+	"cancelled": waitCancelled,
+	"timed_out": waitTimedOut,
+}
+
+// waitSeverity orders the exit codes, so that a failure of any one job
+// takes precedence over a cancelled job.
+var waitSeverity = map[int]int{
+	waitSuccess:   0,
+	waitCancelled: 1,
+	waitTimedOut:  2,
+	waitFailure:   3,
+}
+
+func makeJobsWait() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "wait [JOB_ID|URL...]",
+		Short: "Wait for jobs to complete, and exit with their conclusion",
+		Long: `Wait for jobs given by their ID or URL, or for the jobs selected with the
+same filters as "actuated-cli jobs", to complete.
+
+The build queue is polled until the jobs have left it, then the conclusion
+of each job is looked up with the GitHub API. With filters, jobs which are
+queued whilst waiting are added, and the command waits until the workflow
+runs of the jobs have completed, so that jobs which depend on others are
+included.
+
+The exit code gives the conclusion, for the worst job when there is more
+than one:
+
+  0 success, skipped or neutral
+  1 an error from the CLI
+  2 failure, or any other conclusion such as startup_failure
+  3 cancelled
+  4 timed_out
+  5 --wait-timeout was reached before the jobs completed
+
+--wait-timeout is the total time to wait, the global --timeout still sets
+the timeout for each HTTP request.`,
+		Example: `  # Wait for the jobs of the release workflow to complete
+  actuated-cli jobs wait --repo acme/api --workflow release --wait-timeout 30m
+
+  # Wait for a job in the queue by its ID
+  actuated-cli jobs wait 21234567890
+
+  # Wait for a job by its URL, then tag a release when it succeeds
+  actuated-cli jobs wait https://github.com/acme/api/runs/5678 && ./tag.sh
+`,
+	}
+
+	cmd.RunE = runJobsWaitE
+
+	cmd.Flags().Duration("wait-timeout", 0, "Maximum time to wait for the jobs to complete, 0 for no limit")
+	cmd.Flags().Duration("interval", time.Second*10, "Interval between polls of the API")
+	addJobFilterFlags(cmd)
+
+	return cmd
+}
+
+// waitJob is a job being waited on.
+type waitJob struct {
+	Ref    jobRef
+	Status pkg.JobStatus
+
+	// InQueue is set whilst the job is in the build queue
+	InQueue bool
+
+	// Completed is set when GitHub shows the job has completed
+	Completed  bool
+	Conclusion string
+}
+
+func (j *waitJob) state() string {
+	switch {
+	case j.Completed:
+		return "completed (" + j.Conclusion + ")"
+	case len(j.Status.Status) > 0:
+		return j.Status.Status
+	}
+	return "waiting"
+}
+
+func runJobsWaitE(cmd *cobra.Command, args []string) error {
+	timeout, err := cmd.Flags().GetDuration("wait-timeout")
+	if err != nil {
+		return err
+	}
+
+	interval, err := cmd.Flags().GetDuration("interval")
+	if err != nil {
+		return err
+	}
+
+	if interval < minWatchInterval {
+		return fmt.Errorf("--interval must be at least %s", minWatchInterval)
+	}
+
+	filter, err := getJobFilter(cmd)
+	if err != nil {
+		return err
+	}
+
+	// With arguments the jobs are fixed, otherwise they are found by the
+	// filters on each poll
+	var tracked []*waitJob
+	if len(args) > 0 {
+		refs, _, err := selectJobs(cmd, args)
+		if err != nil {
+			return err
+		}
+		for _, ref := range refs {
+			tracked = append(tracked, &waitJob{Ref: ref})
+		}
+	} else if !filter.hasSelection() {
+		return fmt.Errorf("give a job ID or URL, or filters such as --repo and --workflow to select jobs from the queue")
+	}
+
+	pat, err := getPat(cmd)
+	if err != nil {
+		return err
+	}
+
+	staff, err := getStaff(cmd)
+	if err != nil {
+		return err
+	}
+
+	if len(pat) == 0 {
+		return fmt.Errorf("pat is required")
+	}
+
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	ghClient, err := newGitHubClient(cmd, pat)
+	if err != nil {
+		return err
+	}
+
+	ctx := cmd.Context()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	w := os.Stdout
+	inPlace := isTerminal(w)
+	s := &screen{w: w}
+	states := map[int64]string{}
+
+	for {
+		tracked, err = pollWaitJobs(ctx, cmd, c, ghClient, pat, staff, filter, len(args) == 0, tracked)
+		if err != nil && ctx.Err() == nil {
+			if isUnauthorized(err) {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", err)
+		}
+
+		if ctx.Err() == nil {
+			done, err := waitComplete(ctx, ghClient, tracked, len(args) == 0)
+			if err != nil && ctx.Err() == nil {
+				fmt.Fprintf(os.Stderr, "Warning: %s\n", err)
+			}
+			if done {
+				s.erase()
+				return waitResult(w, tracked)
+			}
+		}
+
+		// Redraw the progress in a terminal, otherwise print each change
+		// in state, which is easier to follow in the logs of a script
+		if inPlace {
+			buf := &bytes.Buffer{}
+			printWaitProgress(buf, tracked, time.Now())
+			s.draw(buf.Bytes())
+		} else {
+			for _, job := range tracked {
+				if state := job.state(); states[job.Ref.JobID] != state {
+					states[job.Ref.JobID] = state
+					fmt.Fprintf(w, "%s: %s\n", job.Ref, state)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return &ExitError{Code: waitDeadline, Err: fmt.Errorf("timed out after %s waiting for jobs to complete", timeout)}
+			}
+			return fmt.Errorf("interrupted whilst waiting for jobs to complete")
+		case <-time.After(interval):
+		}
+	}
+}
+
+// pollWaitJobs updates the jobs from the build queue, and from GitHub once
+// they have left it. When discover is set, jobs which match the filter are
+// added.
+func pollWaitJobs(ctx context.Context, cmd *cobra.Command, c *pkg.Client, ghClient *github.Client, pat string, staff bool, filter jobFilter, discover bool, tracked []*waitJob) ([]*waitJob, error) {
+	statuses, err := c.ListJobs(ctx, pat, getOwner(""), staff)
+	if err != nil {
+		return tracked, err
+	}
+
+	queue := map[int64]pkg.JobStatus{}
+	for _, status := range statuses {
+		queue[status.JobID] = status
+	}
+
+	if discover {
+		known := map[int64]bool{}
+		for _, job := range tracked {
+			known[job.Ref.JobID] = true
+		}
+
+		for _, status := range filter.apply(statuses, time.Now()) {
+			if !known[status.JobID] {
+				tracked = append(tracked, &waitJob{
+					Ref: jobRef{Owner: status.Owner, Repo: status.Repo, JobID: status.JobID, Name: jobName(status)},
+				})
+			}
+		}
+	}
+
+	for _, job := range tracked {
+		if job.Completed {
+			continue
+		}
+
+		status, ok := queue[job.Ref.JobID]
+		job.InQueue = ok
+		if ok {
+			status.URL = jobURL(cmd, status)
+			job.Status = status
+			continue
+		}
+
+		// The queue drops jobs once they complete, so the conclusion is
+		// only available from GitHub
+		ghJob, _, err := ghClient.Actions.GetWorkflowJobByID(ctx, job.Ref.Owner, job.Ref.Repo, job.Ref.JobID)
+		if err != nil {
+			return tracked, fmt.Errorf("unable to find %s on GitHub: %w", job.Ref, err)
+		}
+
+		job.Ref.RunID = ghJob.GetRunID()
+		job.Status.Status = ghJob.GetStatus()
+		if ghJob.GetStatus() == "completed" {
+			job.Completed = true
+			job.Conclusion = ghJob.GetConclusion()
+			job.Status.StartedAt = ghJob.StartedAt.GetTime()
+			job.Status.CompletedAt = ghJob.CompletedAt.GetTime()
+		}
+	}
+
+	return tracked, nil
+}
+
+// waitComplete reports whether every job has completed. When jobs were
+// found by filters, their workflow runs must also have completed, as jobs
+// which depend on others are only queued once those have completed.
+func waitComplete(ctx context.Context, ghClient *github.Client, tracked []*waitJob, runs bool) (bool, error) {
+	if len(tracked) == 0 {
+		return false, nil
+	}
+
+	for _, job := range tracked {
+		if !job.Completed {
+			return false, nil
+		}
+	}
+
+	if !runs {
+		return true, nil
+	}
+
+	checked := map[int64]bool{}
+	for _, job := range tracked {
+		if checked[job.Ref.RunID] {
+			continue
+		}
+
+		run, _, err := ghClient.Actions.GetWorkflowRunByID(ctx, job.Ref.Owner, job.Ref.Repo, job.Ref.RunID)
+		if err != nil {
+			return false, err
+		}
+		if run.GetStatus() != "completed" {
+			return false, nil
+		}
+		checked[job.Ref.RunID] = true
+	}
+
+	return true, nil
+}
+
+// waitResult prints the conclusion of each job, and returns the exit code
+// for the worst of them.
+func waitResult(w io.Writer, tracked []*waitJob) error {
+	code := waitSuccess
+	for _, job := range tracked {
+		jobCode, ok := waitConclusions[job.Conclusion]
+		if !ok {
+			jobCode = waitFailure
+		}
+		if waitSeverity[jobCode] > waitSeverity[code] {
+			code = jobCode
+		}
+
+		duration := ""
+		if job.Status.StartedAt != nil && job.Status.CompletedAt != nil {
+			duration = fmt.Sprintf(" in %s", job.Status.CompletedAt.Sub(*job.Status.StartedAt).Round(time.Second))
+		}
+		fmt.Fprintf(w, "%s: %s%s\n", job.Ref, job.Conclusion, duration)
+	}
+
+	if code == waitSuccess {
+		return nil
+	}
+	return &ExitError{Code: code}
+}
+
+// printWaitProgress writes a line for each job, with a progress bar based
+// upon the average runtime of running jobs.
+func printWaitProgress(w io.Writer, tracked []*waitJob, now time.Time) {
+	if len(tracked) == 0 {
+		fmt.Fprintln(w, "Waiting for jobs to be queued (Ctrl+C to exit)")
+		return
+	}
+
+	for _, job := range tracked {
+		progress := ""
+		switch {
+		case job.Completed:
+			progress = job.state()
+		case job.Status.Status == "queued":
+			wait, _ := jobWait(job.Status, now)
+			progress = fmt.Sprintf("%s queued for %s", progressBar(0, 10), wait.Round(time.Second))
+		case job.InQueue && job.Status.AverageRuntime > 0 && job.Status.StartedAt != nil:
+			elapsed := now.Sub(*job.Status.StartedAt)
+			progress = fmt.Sprintf("%s %s of ~%s", progressBar(float64(elapsed)/float64(job.Status.AverageRuntime), 10),
+				elapsed.Round(time.Second), job.Status.AverageRuntime.Round(time.Second))
+		case job.InQueue && job.Status.StartedAt != nil:
+			progress = fmt.Sprintf("running for %s", now.Sub(*job.Status.StartedAt).Round(time.Second))
+		default:
+			progress = job.state()
+		}
+
+		fmt.Fprintf(w, "%s: %s\n", job.Ref, progress)
+	}
+}
+
+// jobName returns the workflow and job name of a job, as shown in the
+// JOB/WORKFLOW column.
+func jobName(status pkg.JobStatus) string {
+	if len(status.WorkflowName) > 0 {
+		return status.WorkflowName + "/" + status.JobName
+	}
+	return status.JobName
+}
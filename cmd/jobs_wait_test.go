@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/self-actuated/actuated-cli/pkg"
+)
+
+func TestWaitResult(t *testing.T) {
+	tests := []struct {
+		name        string
+		conclusions []string
+		wantCode    int
+	}{
+		{"success", []string{"success"}, waitSuccess},
+		{"skipped and neutral", []string{"skipped", "neutral", "success"}, waitSuccess},
+		{"failure", []string{"failure"}, waitFailure},
+		{"cancelled", []string{"success", "cancelled"}, waitCancelled},
+		{"timed out", []string{"timed_out"}, waitTimedOut},
+		{"timed out is worse than cancelled", []string{"cancelled", "timed_out", "success"}, waitTimedOut},
+		{"failure is the worst", []string{"timed_out", "failure", "cancelled"}, waitFailure},
+		{"other conclusions are failures", []string{"success", "startup_failure"}, waitFailure},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tracked := []*waitJob{}
+			for i, conclusion := range tc.conclusions {
+				tracked = append(tracked, &waitJob{
+					Ref:        jobRef{Owner: "acme", Repo: "api", JobID: int64(i + 1)},
+					Completed:  true,
+					Conclusion: conclusion,
+				})
+			}
+
+			err := waitResult(&bytes.Buffer{}, tracked)
+
+			code := waitSuccess
+			var exitErr *ExitError
+			if errors.As(err, &exitErr) {
+				code = exitErr.Code
+			} else if err != nil {
+				t.Fatalf("want an ExitError, got %v", err)
+			}
+
+			if code != tc.wantCode {
+				t.Errorf("want exit code %d, got %d", tc.wantCode, code)
+			}
+		})
+	}
+}
+
+func TestWaitResultOutput(t *testing.T) {
+	started := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	completed := started.Add(time.Minute*2 + time.Millisecond*400)
+
+	tracked := []*waitJob{
+		{
+			Ref:        jobRef{Owner: "acme", Repo: "api", JobID: 1, Name: "ci / build"},
+			Status:     pkg.JobStatus{StartedAt: &started, CompletedAt: &completed},
+			Completed:  true,
+			Conclusion: "success",
+		},
+		{
+			Ref:        jobRef{Owner: "acme", Repo: "api", JobID: 2},
+			Completed:  true,
+			Conclusion: "cancelled",
+		},
+	}
+
+	var buf bytes.Buffer
+	waitResult(&buf, tracked)
+
+	want := "acme/api job 1 (ci / build): success in 2m0s\nacme/api job 2: cancelled\n"
+	if got := buf.String(); got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/morikuni/aec"
+	"github.com/self-actuated/actuated-cli/pkg"
+	"github.com/spf13/cobra"
+)
+
+// minWatchInterval protects the API from being polled too often
+const minWatchInterval = time.Second * 2
+
+// jobChange is how a job changed between two polls of --watch.
+type jobChange int
+
+const (
+	jobUnchanged jobChange = iota
+	jobQueued
+	jobStarted
+	jobGone
+)
+
+// highlight colours each line of the cells for a changed job, the table
+// ignores the escape codes when measuring the width of a column.
+func (c jobChange) highlight(cells []string) []string {
+	var colour aec.ANSI
+	switch c {
+	case jobQueued:
+		colour = aec.GreenF
+	case jobStarted:
+		colour = aec.CyanF
+	case jobGone:
+		colour = aec.RedF
+	default:
+		return cells
+	}
+
+	out := make([]string, len(cells))
+	for i, cell := range cells {
+		lines := strings.Split(cell, "\n")
+		for j, line := range lines {
+			if len(line) > 0 {
+				lines[j] = colour.Apply(line)
+			}
+		}
+		out[i] = strings.Join(lines, "\n")
+	}
+	return out
+}
+
+// diffJobs compares the jobs from two polls, jobs which have gone since
+// the previous poll are returned so they can be shown for one more poll.
+func diffJobs(previous, current []pkg.JobStatus) (map[int64]jobChange, []pkg.JobStatus) {
+	changes := map[int64]jobChange{}
+
+	before := map[int64]pkg.JobStatus{}
+	for _, status := range previous {
+		before[status.JobID] = status
+	}
+
+	seen := map[int64]bool{}
+	for _, status := range current {
+		seen[status.JobID] = true
+
+		old, ok := before[status.JobID]
+		switch {
+		case !ok && status.Status == "queued":
+			changes[status.JobID] = jobQueued
+		case !ok, old.Status == "queued" && status.Status != "queued":
+			changes[status.JobID] = jobStarted
+		}
+	}
+
+	gone := []pkg.JobStatus{}
+	for _, status := range previous {
+		if !seen[status.JobID] {
+			changes[status.JobID] = jobGone
+			gone = append(gone, status)
+		}
+	}
+
+	return changes, gone
+}
+
+// watchJobs polls for jobs every interval and redraws the table in place
+// each second, so that the ETA of running jobs keeps counting down
+// between polls. It returns when the command is interrupted.
+func watchJobs(cmd *cobra.Command, c *pkg.Client, pat, owner string, staff bool, filter jobFilter, wide bool, interval time.Duration) error {
+	ctx := cmd.Context()
+	w := os.Stdout
+	inPlace := isTerminal(w)
+	s := &screen{w: w}
+
+	var (
+		statuses []pkg.JobStatus
+		gone     []pkg.JobStatus
+		changes  map[int64]jobChange
+		pollErr  error
+		polled   bool
+		updated  time.Time
+		nextPoll time.Time
+	)
+
+	poll := func() error {
+		current, err := c.ListJobs(ctx, pat, owner, staff)
+		if err != nil {
+			// Keep showing the last jobs, unless the token was rejected,
+			// but without the highlights from the last poll
+			if isUnauthorized(err) {
+				return err
+			}
+			pollErr = err
+			changes, gone = nil, nil
+			return nil
+		}
+
+		current = filter.apply(current, time.Now())
+
+		for i := range current {
+			current[i].URL = jobURL(cmd, current[i])
+		}
+
+		if polled {
+			changes, gone = diffJobs(statuses, current)
+		}
+
+		statuses = current
+		pollErr = nil
+		polled = true
+		updated = time.Now()
+		return nil
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		// Allow for the ticker firing just before the poll is due
+		polledNow := false
+		if time.Until(nextPoll) < time.Millisecond*500 {
+			if err := poll(); err != nil {
+				return err
+			}
+			nextPoll = time.Now().Add(interval)
+			polledNow = true
+		}
+
+		// Redraw each second in a terminal, otherwise print a new table
+		// after each poll
+		if inPlace || polledNow {
+			buf := &bytes.Buffer{}
+			printWatchHeader(buf, statuses, updated, time.Until(nextPoll), pollErr)
+			printEvents(buf, append(append([]pkg.JobStatus{}, statuses...), gone...), wide, changes, nil)
+
+			out := buf.Bytes()
+			if !useColour(w) {
+				out = stripColour(out)
+			}
+
+			if inPlace {
+				s.draw(out)
+			} else {
+				w.Write(out)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func printWatchHeader(w io.Writer, statuses []pkg.JobStatus, updated time.Time, next time.Duration, pollErr error) {
+	queued, running := 0, 0
+	for _, status := range statuses {
+		if status.Status == "queued" {
+			queued++
+		} else {
+			running++
+		}
+	}
+
+	fmt.Fprintf(w, "Queued: %d, running: %d, updated: %s, next update in: %ds (Ctrl+C to exit)\n",
+		queued, running, updated.Format("15:04:05"), int(next.Round(time.Second).Seconds()))
+	fmt.Fprintf(w, "%s %s %s\n", aec.GreenF.Apply("new"), aec.CyanF.Apply("started"), aec.RedF.Apply("gone"))
+
+	if pollErr != nil {
+		fmt.Fprintf(w, "Error: %s\n", pollErr)
+	}
+}
@@ -0,0 +1,441 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPath is a template of text and {expressions} for -o jsonpath=, it
+// supports the subset of kubectl's JSONPath syntax which is useful for the
+// results of this CLI:
+//
+//	{.field.child}           a field of an object
+//	{['field']}              a field with characters such as "." in its name
+//	{[0]} {[-1]}             an element of an array
+//	{[*]} {.*}               all elements of an array, or values of an object
+//	{[?(@.status=="queued")]} elements whose field is equal (or !=) to a value
+//	{range .[*]}...{end}     repeat the template for each element
+//	{"\n"}                   a quoted string
+//
+// An expression without braces is treated as a single expression, so
+// "jsonpath=.[*].repo" is the same as "jsonpath={.[*].repo}".
+type jsonPath struct {
+	nodes []jsonPathNode
+}
+
+type jsonPathNode struct {
+	// text is written as it is when path and body are nil
+	text string
+
+	path []pathSegment
+
+	// isRange repeats body for each result of path
+	isRange bool
+	body    []jsonPathNode
+}
+
+type segmentKind int
+
+const (
+	segmentField segmentKind = iota
+	segmentIndex
+	segmentWildcard
+	segmentFilter
+)
+
+type pathSegment struct {
+	kind  segmentKind
+	field string
+	index int
+
+	// filter is set for segmentFilter
+	filter *pathFilter
+}
+
+// pathFilter matches elements where path compares to value, or where path
+// exists when op is empty.
+type pathFilter struct {
+	path  []pathSegment
+	op    string
+	value string
+}
+
+func parseJSONPath(tmpl string) (*jsonPath, error) {
+	if !strings.Contains(tmpl, "{") {
+		tmpl = "{" + tmpl + "}"
+	}
+
+	nodes, rest, err := parseJSONPathNodes(tmpl, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("unexpected {end}")
+	}
+
+	return &jsonPath{nodes: nodes}, nil
+}
+
+// parseJSONPathNodes parses nodes until the end of the template, or until
+// {end} when inRange is set, and returns the unparsed remainder.
+func parseJSONPathNodes(tmpl string, inRange bool) ([]jsonPathNode, string, error) {
+	nodes := []jsonPathNode{}
+
+	for len(tmpl) > 0 {
+		start := strings.Index(tmpl, "{")
+		if start == -1 {
+			nodes = append(nodes, jsonPathNode{text: tmpl})
+			tmpl = ""
+			break
+		}
+		if start > 0 {
+			nodes = append(nodes, jsonPathNode{text: tmpl[:start]})
+		}
+
+		end := matchingClose(tmpl, start, '{', '}')
+		if end == -1 {
+			return nil, "", fmt.Errorf("unclosed { in: %s", tmpl[start:])
+		}
+
+		expr := strings.TrimSpace(tmpl[start+1 : end])
+		tmpl = tmpl[end+1:]
+
+		switch {
+		case expr == "end":
+			if !inRange {
+				return nil, "", fmt.Errorf("{end} without {range}")
+			}
+			return nodes, tmpl, nil
+
+		case strings.HasPrefix(expr, "range "):
+			path, err := parsePath(strings.TrimPrefix(expr, "range "))
+			if err != nil {
+				return nil, "", err
+			}
+
+			body, rest, err := parseJSONPathNodes(tmpl, true)
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, jsonPathNode{path: path, isRange: true, body: body})
+			tmpl = rest
+
+		case strings.HasPrefix(expr, `"`) || strings.HasPrefix(expr, "'"):
+			text, err := unquote(expr)
+			if err != nil {
+				return nil, "", fmt.Errorf("invalid string %s: %w", expr, err)
+			}
+			nodes = append(nodes, jsonPathNode{text: text})
+
+		default:
+			path, err := parsePath(expr)
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, jsonPathNode{path: path})
+		}
+	}
+
+	if inRange {
+		return nil, "", fmt.Errorf("{range} without {end}")
+	}
+
+	return nodes, tmpl, nil
+}
+
+// matchingClose returns the index of the bracket which closes the one at
+// start, skipping over quoted strings and nested brackets.
+func matchingClose(s string, start int, open, close byte) int {
+	depth := 0
+	var quote byte
+
+	for i := start; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == open:
+			depth++
+		case c == close:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+func unquote(s string) (string, error) {
+	if strings.HasPrefix(s, "'") && strings.HasSuffix(s, "'") && len(s) >= 2 {
+		s = `"` + strings.ReplaceAll(s[1:len(s)-1], `"`, `\"`) + `"`
+	}
+	return strconv.Unquote(s)
+}
+
+// parsePath parses an expression such as .items[*].name, a leading $ or @
+// refers to the current element.
+func parsePath(expr string) ([]pathSegment, error) {
+	p := strings.TrimSpace(expr)
+	p = strings.TrimPrefix(p, "$")
+	p = strings.TrimPrefix(p, "@")
+
+	segments := []pathSegment{}
+
+	for i := 0; i < len(p); {
+		switch p[i] {
+		case '.':
+			i++
+			if i < len(p) && p[i] == '*' {
+				segments = append(segments, pathSegment{kind: segmentWildcard})
+				i++
+				continue
+			}
+
+			name := readIdentifier(p[i:])
+			i += len(name)
+			if len(name) > 0 {
+				segments = append(segments, pathSegment{kind: segmentField, field: name})
+			}
+
+		case '[':
+			end := matchingClose(p, i, '[', ']')
+			if end == -1 {
+				return nil, fmt.Errorf("unclosed [ in: %s", expr)
+			}
+
+			segment, err := parseBracket(strings.TrimSpace(p[i+1 : end]))
+			if err != nil {
+				return nil, err
+			}
+			segments = append(segments, segment)
+			i = end + 1
+
+		default:
+			// A field without a leading "." is only allowed first, as in {repo}
+			name := readIdentifier(p[i:])
+			if len(name) == 0 || i > 0 {
+				return nil, fmt.Errorf("unexpected %q in: %s", p[i], expr)
+			}
+			segments = append(segments, pathSegment{kind: segmentField, field: name})
+			i += len(name)
+		}
+	}
+
+	return segments, nil
+}
+
+func readIdentifier(s string) string {
+	for i, c := range s {
+		if c == '.' || c == '[' || c == ' ' || c == '=' || c == '!' || c == ')' {
+			return s[:i]
+		}
+	}
+	return s
+}
+
+func parseBracket(inner string) (pathSegment, error) {
+	switch {
+	case inner == "*":
+		return pathSegment{kind: segmentWildcard}, nil
+
+	case strings.HasPrefix(inner, "'") || strings.HasPrefix(inner, `"`):
+		field, err := unquote(inner)
+		if err != nil {
+			return pathSegment{}, fmt.Errorf("invalid field %s: %w", inner, err)
+		}
+		return pathSegment{kind: segmentField, field: field}, nil
+
+	case strings.HasPrefix(inner, "?"):
+		filter, err := parseFilter(inner)
+		if err != nil {
+			return pathSegment{}, err
+		}
+		return pathSegment{kind: segmentFilter, filter: filter}, nil
+	}
+
+	index, err := strconv.Atoi(inner)
+	if err != nil {
+		return pathSegment{}, fmt.Errorf("invalid index [%s]", inner)
+	}
+
+	return pathSegment{kind: segmentIndex, index: index}, nil
+}
+
+// parseFilter parses ?(@.field=="value"), ?(@.field!=1) or ?(@.field)
+func parseFilter(inner string) (*pathFilter, error) {
+	body := strings.TrimSpace(strings.TrimPrefix(inner, "?"))
+	if !strings.HasPrefix(body, "(") || !strings.HasSuffix(body, ")") {
+		return nil, fmt.Errorf("invalid filter [%s], use i.e. [?(@.status==\"queued\")]", inner)
+	}
+	body = strings.TrimSpace(body[1 : len(body)-1])
+
+	filter := &pathFilter{}
+	left := body
+
+	for _, op := range []string{"==", "!="} {
+		if l, r, ok := strings.Cut(body, op); ok {
+			left = l
+			filter.op = op
+
+			value := strings.TrimSpace(r)
+			if strings.HasPrefix(value, "'") || strings.HasPrefix(value, `"`) {
+				v, err := unquote(value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid value %s: %w", value, err)
+				}
+				value = v
+			}
+			filter.value = value
+			break
+		}
+	}
+
+	path, err := parsePath(left)
+	if err != nil {
+		return nil, err
+	}
+	filter.path = path
+
+	return filter, nil
+}
+
+func (jp *jsonPath) execute(w io.Writer, data interface{}) error {
+	return executeJSONPathNodes(w, jp.nodes, data)
+}
+
+func executeJSONPathNodes(w io.Writer, nodes []jsonPathNode, data interface{}) error {
+	for _, node := range nodes {
+		if node.path == nil {
+			if _, err := io.WriteString(w, node.text); err != nil {
+				return err
+			}
+			continue
+		}
+
+		results := evalPath(node.path, data)
+
+		if node.isRange {
+			for _, result := range results {
+				if err := executeJSONPathNodes(w, node.body, result); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		values := make([]string, len(results))
+		for i, result := range results {
+			values[i] = formatValue(result)
+		}
+		if _, err := io.WriteString(w, strings.Join(values, " ")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// evalPath returns the values matched by the path, missing fields and
+// indexes out of range give no values rather than an error.
+func evalPath(path []pathSegment, data interface{}) []interface{} {
+	current := []interface{}{data}
+
+	for _, segment := range path {
+		next := []interface{}{}
+
+		for _, v := range current {
+			switch segment.kind {
+			case segmentField:
+				if m, ok := v.(map[string]interface{}); ok {
+					if item, ok := m[segment.field]; ok {
+						next = append(next, item)
+					}
+				}
+
+			case segmentIndex:
+				if items, ok := v.([]interface{}); ok {
+					i := segment.index
+					if i < 0 {
+						i += len(items)
+					}
+					if i >= 0 && i < len(items) {
+						next = append(next, items[i])
+					}
+				}
+
+			case segmentWildcard:
+				next = append(next, elements(v)...)
+
+			case segmentFilter:
+				for _, item := range elements(v) {
+					if segment.filter.matches(item) {
+						next = append(next, item)
+					}
+				}
+			}
+		}
+
+		current = next
+	}
+
+	return current
+}
+
+// elements returns the items of an array, or the values of an object
+// sorted by key.
+func elements(v interface{}) []interface{} {
+	switch t := v.(type) {
+	case []interface{}:
+		return t
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		items := make([]interface{}, 0, len(t))
+		for _, k := range keys {
+			items = append(items, t[k])
+		}
+		return items
+	}
+	return nil
+}
+
+func (f *pathFilter) matches(item interface{}) bool {
+	results := evalPath(f.path, item)
+
+	if len(f.op) == 0 {
+		for _, r := range results {
+			if r != nil && r != false && r != "" {
+				return true
+			}
+		}
+		return false
+	}
+
+	equal := false
+	for _, r := range results {
+		if formatValue(r) == f.value {
+			equal = true
+			break
+		}
+	}
+
+	if f.op == "!=" {
+		return !equal
+	}
+	return equal
+}
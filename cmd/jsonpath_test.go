@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+const jsonPathTestData = `[
+	{"repo": "api", "status": "queued", "labels": ["actuated", "arm64"], "runner": {"name": "r1"}, "my.field": 1},
+	{"repo": "web", "status": "in_progress", "labels": ["actuated"], "runner": {"name": "r2"}, "count": 3},
+	{"repo": "docs", "status": "queued", "labels": [], "runner": null}
+]`
+
+func TestJSONPath(t *testing.T) {
+	var data interface{}
+	if err := json.Unmarshal([]byte(jsonPathTestData), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{"field of an element", "{[0].repo}", "api"},
+		{"without braces", ".[1].repo", "web"},
+		{"nested field", "{[1].runner.name}", "r2"},
+		{"leading $", "{$[0].repo}", "api"},
+		{"field without a dot", "{range [*]}{repo} {end}", "api web docs "},
+		{"quoted field", "{[0]['my.field']}", "1"},
+		{"double quoted field", `{[0]["repo"]}`, "api"},
+		{"negative index", "{[-1].repo}", "docs"},
+		{"index out of range", "{[5].repo}", ""},
+		{"missing field", "{[0].missing}", ""},
+		{"wildcard", "{[*].repo}", "api web docs"},
+		{"dot wildcard", "{.*.repo}", "api web docs"},
+		{"wildcard of an object sorted by key", "{[1].runner.*}", "r2"},
+		{"array value", "{[0].labels}", `["actuated","arm64"]`},
+		{"number", "{[1].count}", "3"},
+		{"null", "{[2].runner}", ""},
+		{"filter equal", `{[?(@.status=="queued")].repo}`, "api docs"},
+		{"filter single quoted", `{[?(@.status=='in_progress')].repo}`, "web"},
+		{"filter not equal", `{[?(@.status!="queued")].repo}`, "web"},
+		{"filter number", `{[?(@.count==3)].repo}`, "web"},
+		{"filter exists", `{[?(@.count)].repo}`, "web"},
+		{"filter nested exists", `{[?(@.runner.name)].repo}`, "api web"},
+		{"text around expressions", "repo={[0].repo} status={[0].status}", "repo=api status=queued"},
+		{"range", `{range [*]}{.repo}{"\n"}{end}`, "api\nweb\ndocs\n"},
+		{"range with filter", `{range [?(@.status=="queued")]}{.repo},{end}`, "api,docs,"},
+		{"nested range", `{range [*]}{.repo}:{range .labels[*]}{@} {end};{end}`, "api:actuated arm64 ;web:actuated ;docs:;"},
+		{"single quoted literal", `{[0].repo}{'\t'}{[1].repo}`, "api\tweb"},
+		{"braces in a quoted literal", `{"{"}{[0].repo}{"}"}`, "{api}"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			jp, err := parseJSONPath(tc.tmpl)
+			if err != nil {
+				t.Fatalf("parse %q: %s", tc.tmpl, err)
+			}
+
+			var buf bytes.Buffer
+			if err := jp.execute(&buf, data); err != nil {
+				t.Fatalf("execute %q: %s", tc.tmpl, err)
+			}
+
+			if got := buf.String(); got != tc.want {
+				t.Errorf("%q: want %q, got %q", tc.tmpl, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestJSONPathInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		tmpl string
+	}{
+		{"unclosed brace", "{[0].repo"},
+		{"unclosed bracket", "{[0.repo}"},
+		{"invalid index", "{[abc]}"},
+		{"end without range", "{[0].repo}{end}"},
+		{"range without end", "{range [*]}{.repo}"},
+		{"invalid filter", "{[?@.status]}"},
+		{"unterminated string", `{"abc}`},
+		{"invalid string escape", `{"\q"}`},
+		{"invalid filter value", `{[?(@.status=="\q")]}`},
+		{"field after a bracket without a dot", "{[0]repo}"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := parseJSONPath(tc.tmpl); err == nil {
+				t.Errorf("%q: want an error", tc.tmpl)
+			}
+		})
+	}
+}
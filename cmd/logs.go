@@ -2,12 +2,9 @@ package cmd
 
 import (
 	"fmt"
-	"net/http"
-	"os"
 	"strings"
 	"time"
 
-	"github.com/self-actuated/actuated-cli/pkg"
 	"github.com/spf13/cobra"
 )
 
@@ -64,7 +61,7 @@ func runLogsE(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	staff, err := cmd.Flags().GetBool("staff")
+	staff, err := getStaff(cmd)
 	if err != nil {
 		return err
 	}
@@ -73,6 +70,7 @@ func runLogsE(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	owner = getOwner(owner)
 
 	age, err := cmd.Flags().GetDuration("age")
 	if err != nil {
@@ -96,16 +94,15 @@ func runLogsE(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("pat is required")
 	}
 
-	c := pkg.NewClient(http.DefaultClient, os.Getenv("ACTUATED_URL"))
-
-	res, status, err := c.GetLogs(pat, owner, host, id, age, staff)
-
+	c, err := newClient(cmd)
 	if err != nil {
 		return err
 	}
 
-	if status != http.StatusAccepted {
-		return fmt.Errorf("unexpected status code: %d, body: %s", status, res)
+	res, _, err := c.GetLogs(cmd.Context(), pat, owner, host, id, age, staff)
+
+	if err != nil {
+		return err
 	}
 
 	fmt.Println(res)
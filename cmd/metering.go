@@ -2,11 +2,8 @@ package cmd
 
 import (
 	"fmt"
-	"net/http"
-	"os"
 	"strings"
 
-	"github.com/self-actuated/actuated-cli/pkg"
 	"github.com/spf13/cobra"
 )
 
@@ -45,7 +42,7 @@ func runMeteringE(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	staff, err := cmd.Flags().GetBool("staff")
+	staff, err := getStaff(cmd)
 	if err != nil {
 		return err
 	}
@@ -54,6 +51,7 @@ func runMeteringE(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	owner = getOwner(owner)
 
 	id, err := cmd.Flags().GetString("id")
 	if err != nil {
@@ -72,15 +70,14 @@ func runMeteringE(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("pat is required")
 	}
 
-	c := pkg.NewClient(http.DefaultClient, os.Getenv("ACTUATED_URL"))
-
-	res, status, err := c.GetMetering(pat, owner, host, id, staff)
+	c, err := newClient(cmd)
 	if err != nil {
 		return err
 	}
 
-	if status != http.StatusAccepted && status != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d, body: %s", status, res)
+	res, _, err := c.GetMetering(cmd.Context(), pat, owner, host, id, staff)
+	if err != nil {
+		return err
 	}
 
 	fmt.Println(res)
@@ -0,0 +1,358 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats for the -o/--output flag
+const (
+	outputTable      = "table"
+	outputWide       = "wide"
+	outputJSON       = "json"
+	outputYAML       = "yaml"
+	outputCSV        = "csv"
+	outputNDJSON     = "ndjson"
+	outputGoTemplate = "go-template"
+	outputJSONPath   = "jsonpath"
+)
+
+// outputFormat is a format given by -o/--output, Template holds the text
+// after "=" for go-template and jsonpath.
+type outputFormat struct {
+	Name     string
+	Template string
+}
+
+// wide reports whether extra columns should be shown in tables.
+func (o outputFormat) wide() bool {
+	return o.Name == outputWide
+}
+
+// parseOutputFormat parses a value such as "json" or "jsonpath={.name}".
+func parseOutputFormat(v string) (outputFormat, error) {
+	name, tmpl, hasTemplate := strings.Cut(strings.TrimSpace(v), "=")
+
+	switch name {
+	case "", outputTable:
+		return outputFormat{Name: outputTable}, nil
+	case outputWide, outputJSON, outputYAML, outputCSV, outputNDJSON:
+		if hasTemplate {
+			return outputFormat{}, fmt.Errorf("output format %s does not take a template", name)
+		}
+		return outputFormat{Name: name}, nil
+	case outputGoTemplate, outputJSONPath:
+		if len(tmpl) == 0 {
+			return outputFormat{}, fmt.Errorf("output format %s needs a template i.e. -o %s=TEMPLATE", name, name)
+		}
+		return outputFormat{Name: name, Template: tmpl}, nil
+	}
+
+	return outputFormat{}, fmt.Errorf("unknown output format: %q, use one of: table, wide, json, yaml, csv, ndjson, go-template=TEMPLATE, jsonpath=EXPRESSION", v)
+}
+
+// addOutputFlags adds -o/--output to a listing command, along with --json
+// which is kept as an alias for "-o json".
+func addOutputFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("output", "o", "", "Output format: table, wide, json, yaml, csv, ndjson, go-template=TEMPLATE or jsonpath=EXPRESSION")
+	cmd.Flags().BoolP("json", "j", false, "Request output in JSON format, the same as -o json")
+}
+
+// getOutputFormat returns the format from -o/--output or --json, or the
+// output of the active context when neither flag was given.
+func getOutputFormat(cmd *cobra.Command) (outputFormat, error) {
+	if cmd.Flags().Changed("output") {
+		v, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return outputFormat{}, err
+		}
+		return parseOutputFormat(v)
+	}
+
+	if cmd.Flags().Changed("json") {
+		requestJson, err := cmd.Flags().GetBool("json")
+		if err != nil {
+			return outputFormat{}, err
+		}
+		if requestJson {
+			return outputFormat{Name: outputJSON}, nil
+		}
+		return outputFormat{Name: outputTable}, nil
+	}
+
+	if activeContext != nil && len(activeContext.Output) > 0 {
+		return parseOutputFormat(activeContext.Output)
+	}
+
+	return outputFormat{Name: outputTable}, nil
+}
+
+// tablePrinter renders a result as a table, wide adds extra columns.
+type tablePrinter func(w io.Writer, wide bool) error
+
+// recordsFunc returns the header and rows of a result for CSV output.
+type recordsFunc func() ([]string, [][]string)
+
+// printOutput writes v in the format o. The structured formats are
+// rendered from v, and its JSON field names are used by go-template and
+// jsonpath, whilst table and records render the table and CSV formats.
+func printOutput(w io.Writer, o outputFormat, v interface{}, table tablePrinter, records recordsFunc) error {
+	switch o.Name {
+	case outputTable, outputWide:
+		return table(w, o.wide())
+	case outputJSON:
+		out, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(out))
+		return err
+	case outputCSV:
+		header, rows := records()
+		cw := csv.NewWriter(w)
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+		if err := cw.WriteAll(rows); err != nil {
+			return err
+		}
+		return cw.Error()
+	case outputNDJSON:
+		return writeNDJSON(w, v)
+	}
+
+	data, err := toGeneric(v)
+	if err != nil {
+		return err
+	}
+
+	switch o.Name {
+	case outputYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(data); err != nil {
+			return err
+		}
+		return enc.Close()
+	case outputGoTemplate:
+		tmpl, err := template.New("output").Parse(o.Template)
+		if err != nil {
+			return fmt.Errorf("unable to parse go-template: %w", err)
+		}
+		buf := &bytes.Buffer{}
+		if err := tmpl.Execute(buf, data); err != nil {
+			return fmt.Errorf("unable to execute go-template: %w", err)
+		}
+		return writeWithNewline(w, buf.String())
+	case outputJSONPath:
+		jp, err := parseJSONPath(o.Template)
+		if err != nil {
+			return fmt.Errorf("unable to parse jsonpath: %w", err)
+		}
+		buf := &bytes.Buffer{}
+		if err := jp.execute(buf, data); err != nil {
+			return fmt.Errorf("unable to execute jsonpath: %w", err)
+		}
+		return writeWithNewline(w, buf.String())
+	}
+
+	return fmt.Errorf("unknown output format: %s", o.Name)
+}
+
+// writeNDJSON writes each element of a slice as JSON on its own line, any
+// other value is written as a single line.
+func writeNDJSON(w io.Writer, v interface{}) error {
+	items := []interface{}{v}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice {
+		items = make([]interface{}, rv.Len())
+		for i := range items {
+			items[i] = rv.Index(i).Interface()
+		}
+	}
+
+	for _, item := range items {
+		out, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w, string(out)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeWithNewline(w io.Writer, s string) error {
+	if len(s) > 0 && !strings.HasSuffix(s, "\n") {
+		s += "\n"
+	}
+	_, err := io.WriteString(w, s)
+	return err
+}
+
+// toGeneric converts v to maps and slices keyed by its JSON field names,
+// keeping integers such as job IDs as int64 rather than float64.
+func toGeneric(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var out interface{}
+	if err := dec.Decode(&out); err != nil {
+		return nil, err
+	}
+
+	return convertNumbers(out), nil
+}
+
+func convertNumbers(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		f, _ := t.Float64()
+		return f
+	case map[string]interface{}:
+		for k, item := range t {
+			t[k] = convertNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range t {
+			t[i] = convertNumbers(item)
+		}
+	}
+	return v
+}
+
+// formatValue renders a decoded JSON value for a table cell or jsonpath,
+// objects and arrays are rendered as JSON.
+func formatValue(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case int64, bool:
+		return fmt.Sprintf("%v", t)
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Map || rv.Kind() == reflect.Slice {
+		out, err := json.Marshal(v)
+		if err == nil {
+			return string(out)
+		}
+	}
+
+	return fmt.Sprintf("%v", v)
+}
+
+// genericRecords returns a header and rows for a decoded JSON value whose
+// shape is decided by the server. An array of objects gives a column per
+// key, an object gives a row per key.
+func genericRecords(data interface{}) ([]string, [][]string) {
+	switch t := data.(type) {
+	case []interface{}:
+		keys := map[string]bool{}
+		for _, item := range t {
+			if m, ok := item.(map[string]interface{}); ok {
+				for k := range m {
+					keys[k] = true
+				}
+			}
+		}
+
+		if len(keys) == 0 {
+			rows := [][]string{}
+			for _, item := range t {
+				rows = append(rows, []string{formatValue(item)})
+			}
+			return []string{"value"}, rows
+		}
+
+		header := make([]string, 0, len(keys))
+		for k := range keys {
+			header = append(header, k)
+		}
+		sort.Strings(header)
+
+		rows := [][]string{}
+		for _, item := range t {
+			m, _ := item.(map[string]interface{})
+			row := make([]string, len(header))
+			for i, k := range header {
+				row[i] = formatValue(m[k])
+			}
+			rows = append(rows, row)
+		}
+		return header, rows
+
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		rows := [][]string{}
+		for _, k := range keys {
+			rows = append(rows, []string{k, formatValue(t[k])})
+		}
+		return []string{"key", "value"}, rows
+	}
+
+	return []string{"value"}, [][]string{{formatValue(data)}}
+}
+
+// ansiEscape matches the colour codes added by aec
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// useColour reports whether colours should be written to w, they are only
+// used for a terminal, and can be turned off by setting NO_COLOR.
+func useColour(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && isTerminal(f) && len(os.Getenv("NO_COLOR")) == 0
+}
+
+// stripColour removes colour codes from the output.
+func stripColour(out []byte) []byte {
+	return ansiEscape.ReplaceAll(out, nil)
+}
+
+// renderTable writes a table in the style used by the listing commands,
+// headers are upper-cased.
+func renderTable(w io.Writer, header []string, rows [][]string) {
+	table := tablewriter.NewWriter(w)
+
+	table.SetHeader(header)
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+	table.SetAutoWrapText(false)
+	table.AppendBulk(rows)
+
+	table.Render()
+}
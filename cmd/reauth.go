@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/v76/github"
+	"github.com/self-actuated/actuated-cli/pkg"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// isUnauthorized reports whether err is a 401 from the actuated API or
+// from the GitHub API.
+func isUnauthorized(err error) bool {
+	if pkg.IsUnauthorized(err) {
+		return true
+	}
+
+	var ghErr *github.ErrorResponse
+	return errors.As(err, &ghErr) && ghErr.Response != nil &&
+		ghErr.Response.StatusCode == http.StatusUnauthorized
+}
+
+// invalidTokenError explains why a token was rejected and how to obtain a
+// new one.
+func invalidTokenError(err error, source string) error {
+	if len(source) == 0 {
+		source = "the token"
+	}
+
+	return fmt.Errorf("%s was rejected, the token may have expired, been revoked, or be missing the scopes: %s, run \"actuated-cli auth\" to obtain a new token: %w",
+		source, strings.Join(requiredScopes, ", "), err)
+}
+
+// reauthenticate is called when a command fails with a 401. When the token
+// came from a file and the CLI is running in a terminal, the user is
+// offered the device flow, after which the new token is saved and the
+// command is run again. reauthenticate returns ok when the command should
+// be run again.
+func reauthenticate(cmd *cobra.Command, cmdErr error) (bool, error) {
+	_, source, err := getPatWithSource(cmd)
+	if err != nil {
+		return false, invalidTokenError(cmdErr, "")
+	}
+
+	patFile, fromFile := strings.CutPrefix(source, "file ")
+	if !fromFile || !isTerminal(os.Stdin) || !isTerminal(os.Stderr) {
+		return false, invalidTokenError(cmdErr, "the token from "+source)
+	}
+
+	fmt.Fprintf(os.Stderr, "The token from %s was rejected by %s.\n", patFile, rejectedBy(cmdErr))
+	fmt.Fprintf(os.Stderr, "It may have expired, been revoked, or be missing the scopes: %s\n", strings.Join(requiredScopes, ", "))
+
+	if !confirm("Authenticate with GitHub and run the command again? [y/N] ") {
+		return false, invalidTokenError(cmdErr, "the token from "+source)
+	}
+
+	token, err := deviceFlowLogin(cmd, deviceFlowOptions{OpenBrowser: true})
+	if err != nil {
+		return false, err
+	}
+
+	if err := writePatFile(patFile, token, isEncryptedPatFile(patFile)); err != nil {
+		return false, err
+	}
+
+	fmt.Fprintf(os.Stderr, "Access token written to: %s\n\n", patFile)
+
+	resetFlags(cmd)
+
+	return true, nil
+}
+
+func rejectedBy(err error) string {
+	if pkg.IsUnauthorized(err) {
+		return "the actuated API"
+	}
+	return "GitHub"
+}
+
+// confirm prints the prompt to stderr and reports whether the user
+// answered yes.
+func confirm(prompt string) bool {
+	fmt.Fprint(os.Stderr, prompt)
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// isTerminal reports whether f is a terminal rather than a pipe or file.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// resetFlags restores the flags of cmd and its parents to their defaults,
+// so that the command line can be parsed again without slice flags
+// accumulating values from the first run.
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if !f.Changed {
+			return
+		}
+		if v, ok := f.Value.(pflag.SliceValue); ok {
+			_ = v.Replace(nil)
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+
+	for c := cmd; c != nil; c = c.Parent() {
+		c.Flags().VisitAll(reset)
+		c.PersistentFlags().VisitAll(reset)
+	}
+}
@@ -1,13 +1,9 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
-	"net/http"
-	"os"
 	"strings"
 
-	"github.com/self-actuated/actuated-cli/pkg"
 	"github.com/spf13/cobra"
 )
 
@@ -39,13 +35,14 @@ func runRepairE(cmd *cobra.Command, args []string) error {
 	if len(args) == 1 {
 		owner = strings.TrimSpace(args[0])
 	}
+	owner = getOwner(owner)
 
 	pat, err := getPat(cmd)
 	if err != nil {
 		return err
 	}
 
-	staff, err := cmd.Flags().GetBool("staff")
+	staff, err := getStaff(cmd)
 	if err != nil {
 		return err
 	}
@@ -58,31 +55,23 @@ func runRepairE(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("pat is required")
 	}
 
-	c := pkg.NewClient(http.DefaultClient, os.Getenv("ACTUATED_URL"))
-
-	res, status, err := c.Repair(pat, owner, staff)
+	c, err := newClient(cmd)
 	if err != nil {
 		return err
 	}
 
-	if status != http.StatusOK && status != http.StatusAccepted &&
-		status != http.StatusNoContent && status != http.StatusCreated {
-		return fmt.Errorf("unexpected status code: %d", status)
+	res, err := c.Repair(cmd.Context(), pat, owner, staff)
+	if err != nil {
+		return err
 	}
 
-	fmt.Printf("Repair requested for %s, status: %d\n", owner, status)
-	if strings.TrimSpace(res) != "" {
-		repairRes := RepairRes{}
-		if err := json.Unmarshal([]byte(res), &repairRes); err != nil {
-			return err
-		}
+	fmt.Printf("Repair requested for %s\n", owner)
 
-		fmt.Printf("Requeued VMs: %d\n", repairRes.VMs)
+	if res.VMs != nil {
+		fmt.Printf("Requeued VMs: %d\n", *res.VMs)
+	} else if len(res.Message) > 0 {
+		fmt.Println(res.Message)
 	}
 
 	return nil
 }
-
-type RepairRes struct {
-	VMs int `json:"vms"`
-}
@@ -2,11 +2,8 @@ package cmd
 
 import (
 	"fmt"
-	"net/http"
-	"os"
 	"strings"
 
-	"github.com/self-actuated/actuated-cli/pkg"
 	"github.com/spf13/cobra"
 )
 
@@ -44,7 +41,7 @@ func runRestartE(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	staff, err := cmd.Flags().GetBool("staff")
+	staff, err := getStaff(cmd)
 	if err != nil {
 		return err
 	}
@@ -53,6 +50,7 @@ func runRestartE(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	owner = getOwner(owner)
 
 	reboot, err := cmd.Flags().GetBool("reboot")
 	if err != nil {
@@ -67,16 +65,14 @@ func runRestartE(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("pat is required")
 	}
 
-	c := pkg.NewClient(http.DefaultClient, os.Getenv("ACTUATED_URL"))
-
-	res, status, err := c.RestartAgent(pat, owner, host, reboot, staff)
+	c, err := newClient(cmd)
 	if err != nil {
 		return err
 	}
 
-	if status != http.StatusOK && status != http.StatusAccepted &&
-		status != http.StatusNoContent && status != http.StatusCreated {
-		return fmt.Errorf("unexpected status code: %d, error: %s", status, res)
+	res, status, err := c.RestartAgent(cmd.Context(), pat, owner, host, reboot, staff)
+	if err != nil {
+		return err
 	}
 
 	fmt.Printf("Restart requested for %s, status: %d\n", owner, status)
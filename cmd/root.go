@@ -1,10 +1,15 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
+	"github.com/self-actuated/actuated-cli/pkg"
 	"github.com/spf13/cobra"
 )
 
@@ -34,10 +39,23 @@ https://github.com/self-actuated/actuated-cli
 	root.PersistentFlags().String("token-value", "", "Personal Access Token")
 	root.PersistentFlags().StringP("token", "t", "$HOME/.actuated/PAT", "File to read for Personal Access Token")
 	root.PersistentFlags().BoolP("staff", "s", false, "Execute the command as an actuated staff member")
+	root.PersistentFlags().String("github-host", defaultGitHubHost, "GitHub host, set for GitHub Enterprise Server, also set with ACTUATED_GITHUB_HOST")
+	root.PersistentFlags().String("context", "", "Context to use from the config file, instead of current-context")
+	root.PersistentFlags().Bool("debug", false, "Print a trace of each HTTP request and response to stderr, also enabled with DEBUG=1")
+	root.PersistentFlags().Duration("timeout", 0, "Timeout for each HTTP request i.e. 30s, 0 means no timeout")
+
+	defaultRetry := pkg.DefaultRetryPolicy()
+	root.PersistentFlags().Int("retries", defaultRetry.MaxRetries, "Retries for read requests which fail with a transient error, 0 to disable")
+	root.PersistentFlags().Duration("retry-max-wait", defaultRetry.MaxBackoff, "Maximum wait between retries, including any Retry-After from the server")
+	root.PersistentFlags().Bool("retry-mutating", false, "Also retry requests which change state, such as restart, disable, upgrade and repair")
 
 	root.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
-		if v, ok := os.LookupEnv("ACTUATED_URL"); !ok || v == "" {
-			return fmt.Errorf(`ACTUATED_URL environment variable is not set, see the CLI tab in the dashboard for instructions`)
+		if err := loadActiveContext(cmd); err != nil {
+			return err
+		}
+
+		if v := actuatedURL(); v == "" {
+			return fmt.Errorf(`ACTUATED_URL environment variable is not set, see the CLI tab in the dashboard for instructions, or use "actuated-cli config" to add a context`)
 		} else if strings.Contains(v, "o6s.io") {
 			return fmt.Errorf("the ACTUATED_URL loaded from your shell is out of date, visit https://dashboard.actuated.com and click \"CLI\" for the latest URL and edit export ACTUATED_URL=... in your bash or zsh profile")
 		}
@@ -50,6 +68,7 @@ https://github.com/self-actuated/actuated-cli
 
 	root.AddCommand(makeRunners())
 	root.AddCommand(makeJobs())
+	root.AddCommand(makeDescribe())
 	root.AddCommand(makeRepair())
 	root.AddCommand(makeIncreases())
 
@@ -59,54 +78,134 @@ https://github.com/self-actuated/actuated-cli
 	root.AddCommand(makeUpgrade())
 	root.AddCommand(makeLogs())
 
+	root.AddCommand(makeConfig())
 	root.AddCommand(makeController())
 	root.AddCommand(makeMetering())
 }
 
 func Execute() error {
-	return root.Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	c, err := root.ExecuteContextC(ctx)
+	if err == nil || !isUnauthorized(err) {
+		return err
+	}
+
+	// The token was rejected, offer to authenticate again and re-run
+	// the command
+	retry, err := reauthenticate(c, err)
+	if !retry {
+		return err
+	}
+
+	return root.ExecuteContext(ctx)
 }
 
-func getPat(cmd *cobra.Command) (string, error) {
-	var (
-		pat,
-		patFile string
-	)
+// ExitError is returned by commands which exit with a specific code, such
+// as "jobs check". The command has already printed its output, so only Err
+// is printed, when it is set.
+type ExitError struct {
+	Code int
+	Err  error
+}
 
-	if cmd.Flags().Changed("token-value") {
-		v, err := cmd.Flags().GetString("token-value")
-		if err != nil {
-			return "", err
-		}
-		pat = v
-	} else {
-		v, err := cmd.Flags().GetString("token")
-		if err != nil {
-			return "", err
-		}
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit code %d", e.Code)
+	}
+	return e.Err.Error()
+}
 
-		if len(v) == 0 {
-			return "", fmt.Errorf("give --token or --token-value")
-		}
-		patFile = os.ExpandEnv(v)
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// newHTTPClient returns a HTTP client which applies the --timeout flag
+// to each request, and traces requests to stderr when --debug is given.
+func newHTTPClient(cmd *cobra.Command) (*http.Client, error) {
+	timeout, err := cmd.Root().PersistentFlags().GetDuration("timeout")
+	if err != nil {
+		return nil, err
 	}
 
-	if len(patFile) > 0 {
-		v, err := readPatFile(patFile)
-		if err != nil {
-			return "", err
-		}
-		pat = v
+	debug, err := debugEnabled(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	var transport http.RoundTripper = http.DefaultTransport
+	if debug {
+		transport = pkg.DebugMiddleware(os.Stderr)(transport)
 	}
 
-	return pat, nil
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}, nil
 }
 
-func readPatFile(filePath string) (string, error) {
-	patData, err := os.ReadFile(os.ExpandEnv(filePath))
+// debugEnabled reports whether --debug was given or DEBUG=1 is set.
+func debugEnabled(cmd *cobra.Command) (bool, error) {
+	debug, err := cmd.Root().PersistentFlags().GetBool("debug")
 	if err != nil {
-		return "", err
+		return false, err
+	}
+
+	return debug || os.Getenv("DEBUG") == "1", nil
+}
+
+// newClient returns a client for the actuated API at ACTUATED_URL, or the
+// URL of the active context.
+func newClient(cmd *cobra.Command) (*pkg.Client, error) {
+	httpClient, err := newHTTPClient(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	retryPolicy, err := getRetryPolicy(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	// The transport from newHTTPClient already traces requests when
+	// debugging, so the client's own tracing is turned off.
+	return pkg.NewClient(httpClient, actuatedURL(),
+		pkg.WithRetryPolicy(retryPolicy),
+		pkg.WithDebug(nil)), nil
+}
+
+// getRetryPolicy returns the retry policy given by the --retries,
+// --retry-max-wait and --retry-mutating flags.
+func getRetryPolicy(cmd *cobra.Command) (pkg.RetryPolicy, error) {
+	policy := pkg.DefaultRetryPolicy()
+	flags := cmd.Root().PersistentFlags()
+
+	retries, err := flags.GetInt("retries")
+	if err != nil {
+		return policy, err
+	}
+	if retries < 0 {
+		return policy, fmt.Errorf("--retries must be 0 or greater")
+	}
+
+	maxWait, err := flags.GetDuration("retry-max-wait")
+	if err != nil {
+		return policy, err
+	}
+
+	retryMutating, err := flags.GetBool("retry-mutating")
+	if err != nil {
+		return policy, err
+	}
+
+	policy.MaxRetries = retries
+	policy.MaxBackoff = maxWait
+	policy.RetryMutating = retryMutating
+
+	if policy.InitialBackoff > maxWait {
+		policy.InitialBackoff = maxWait
 	}
 
-	return strings.TrimSpace(string(patData)), nil
+	return policy, nil
 }
@@ -1,11 +1,12 @@
 package cmd
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
-	"net/http"
+	"io"
 	"os"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/self-actuated/actuated-cli/pkg"
@@ -23,14 +24,22 @@ func makeRunners() *cobra.Command {
   actuated-cli runners --staff OWNER
 
   # List runners in JSON format
-  actuated-cli runners --json OWNER
+  actuated-cli runners -o json OWNER
+
+  # Show the runners which are online, with the most VMs first
+  actuated-cli runners --filter status=online --sort-by vms
+
+  # Print the name of each runner which is not reachable
+  actuated-cli runners -o 'jsonpath={range [?(@.reachable==false)]}{.name}{"\n"}{end}'
 `,
 	}
 
 	cmd.RunE = runRunnersE
 
 	cmd.Flags().Bool("images", false, "Show the image being used for the rootfs and Kernel")
-	cmd.Flags().BoolP("json", "j", false, "Request output in JSON format")
+	cmd.Flags().String("sort-by", "", "Sort by a column: "+strings.Join(runnerColumns, ", "))
+	cmd.Flags().StringArray("filter", nil, "Only show runners where a column matches, i.e. status=online, can be given more than once")
+	addOutputFlags(cmd)
 
 	return cmd
 }
@@ -46,18 +55,29 @@ func runRunnersE(cmd *cobra.Command, args []string) error {
 	if len(args) == 1 {
 		owner = strings.TrimSpace(args[0])
 	}
+	owner = getOwner(owner)
 
 	pat, err := getPat(cmd)
 	if err != nil {
 		return err
 	}
 
-	staff, err := cmd.Flags().GetBool("staff")
+	staff, err := getStaff(cmd)
+	if err != nil {
+		return err
+	}
+
+	output, err := getOutputFormat(cmd)
 	if err != nil {
 		return err
 	}
 
-	requestJson, err := cmd.Flags().GetBool("json")
+	sortBy, err := cmd.Flags().GetString("sort-by")
+	if err != nil {
+		return err
+	}
+
+	filters, err := cmd.Flags().GetStringArray("filter")
 	if err != nil {
 		return err
 	}
@@ -66,28 +86,207 @@ func runRunnersE(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("pat is required")
 	}
 
-	c := pkg.NewClient(http.DefaultClient, os.Getenv("ACTUATED_URL"))
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	runners, err := c.ListRunners(cmd.Context(), pat, owner, staff, images)
+	if err != nil {
+		return err
+	}
 
-	res, status, err := c.ListRunners(pat, owner, staff, images, requestJson)
+	runners, err = filterRunners(runners, filters)
 	if err != nil {
 		return err
 	}
 
-	if status != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d, message: %s", status, res)
+	if err := sortRunners(runners, sortBy); err != nil {
+		return err
+	}
+
+	return printOutput(os.Stdout, output, runners,
+		func(w io.Writer, wide bool) error {
+			columns := []string{"name", "customer", "status", "reachable", "cpus", "ram", "vms", "version"}
+			if images || wide {
+				columns = append(columns, "kernel", "rootfs")
+			}
+
+			// Values which were not returned are shown as "-", rather than
+			// as 0 which looks like a real value
+			rows := runnerRecords(runners, columns)
+			for _, row := range rows {
+				for i := range row {
+					if len(row[i]) == 0 {
+						row[i] = "-"
+					}
+				}
+			}
+
+			renderTable(w, columns, rows)
+			return nil
+		},
+		func() ([]string, [][]string) {
+			// Memory is given in bytes for scripts
+			columns := []string{"name", "customer", "status", "reachable", "cpus", "memory", "vms", "version", "kernel", "rootfs"}
+			return columns, runnerRecords(runners, columns)
+		})
+}
+
+// runnerColumns can be given to --sort-by and --filter
+var runnerColumns = []string{"name", "customer", "status", "reachable", "cpus", "ram", "vms", "version", "kernel", "rootfs"}
+
+// runnerValue returns the value of a column for --filter and the table, or
+// an empty string when the server did not return it.
+func runnerValue(runner pkg.Runner, column string) string {
+	switch column {
+	case "name":
+		return runner.Name
+	case "customer":
+		return runner.Customer
+	case "status":
+		return runner.Status
+	case "reachable":
+		return strconv.FormatBool(runner.Reachable)
+	case "cpus":
+		if runner.CPUs > 0 {
+			return strconv.Itoa(runner.CPUs)
+		}
+	case "ram":
+		return formatBytes(runner.Memory)
+	case "memory":
+		if runner.Memory > 0 {
+			return strconv.FormatInt(runner.Memory, 10)
+		}
+	case "vms":
+		if runner.RunningVMs != nil {
+			return strconv.Itoa(*runner.RunningVMs)
+		}
+	case "version":
+		return runner.AgentVersion
+	case "kernel":
+		return runner.Kernel
+	case "rootfs":
+		return runner.Rootfs
+	}
+	return ""
+}
+
+// runnerRecords returns a row per runner with the values of the columns.
+func runnerRecords(runners []pkg.Runner, columns []string) [][]string {
+	rows := [][]string{}
+	for _, runner := range runners {
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			row[i] = runnerValue(runner, column)
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// filterRunners keeps the runners which match every filter, each given as
+// COLUMN=VALUE, values are compared without case.
+func filterRunners(runners []pkg.Runner, filters []string) ([]pkg.Runner, error) {
+	if len(filters) == 0 {
+		return runners, nil
+	}
+
+	type filter struct{ column, value string }
+	parsed := []filter{}
+
+	for _, f := range filters {
+		column, value, ok := strings.Cut(f, "=")
+		column = strings.ToLower(strings.TrimSpace(column))
+		if !ok || !slices.Contains(runnerColumns, column) {
+			return nil, fmt.Errorf("invalid filter: %q, use COLUMN=VALUE where COLUMN is one of: %s", f, strings.Join(runnerColumns, ", "))
+		}
+		parsed = append(parsed, filter{column: column, value: strings.TrimSpace(value)})
+	}
+
+	matched := []pkg.Runner{}
+	for _, runner := range runners {
+		ok := true
+		for _, f := range parsed {
+			if !strings.EqualFold(runnerValue(runner, f.column), f.value) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			matched = append(matched, runner)
+		}
+	}
+
+	return matched, nil
+}
+
+// sortRunners sorts by a column, numeric columns are sorted with the
+// largest first, and the name is used to break ties.
+func sortRunners(runners []pkg.Runner, column string) error {
+	column = strings.ToLower(strings.TrimSpace(column))
+	if len(column) == 0 {
+		return nil
 	}
 
-	if requestJson {
+	if !slices.Contains(runnerColumns, column) {
+		return fmt.Errorf("invalid --sort-by: %q, use one of: %s", column, strings.Join(runnerColumns, ", "))
+	}
 
-		var prettyJSON bytes.Buffer
-		err := json.Indent(&prettyJSON, []byte(res), "", "  ")
-		if err != nil {
-			return err
+	// Missing values are sorted last
+	numeric := func(r pkg.Runner) int64 {
+		switch column {
+		case "cpus":
+			return int64(r.CPUs)
+		case "ram":
+			return r.Memory
+		case "vms":
+			if r.RunningVMs != nil {
+				return int64(*r.RunningVMs)
+			}
+			return -1
 		}
-		res = prettyJSON.String()
+		return 0
 	}
-	fmt.Println(res)
+
+	sort.SliceStable(runners, func(i, j int) bool {
+		a, b := runners[i], runners[j]
+
+		switch column {
+		case "cpus", "ram", "vms":
+			if numeric(a) != numeric(b) {
+				return numeric(a) > numeric(b)
+			}
+		default:
+			if va, vb := runnerValue(a, column), runnerValue(b, column); va != vb {
+				return va < vb
+			}
+		}
+
+		return a.Name < b.Name
+	})
 
 	return nil
+}
+
+// formatBytes returns a size such as 16GiB, or an empty string for 0.
+func formatBytes(n int64) string {
+	if n <= 0 {
+		return ""
+	}
+
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
 
+	v := strings.TrimSuffix(fmt.Sprintf("%.1f", float64(n)/float64(div)), ".0")
+	return fmt.Sprintf("%s%ciB", v, "KMGTPE"[exp])
 }
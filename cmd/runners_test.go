@@ -0,0 +1,27 @@
+package cmd
+
+import "testing"
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, ""},
+		{-1, ""},
+		{1, "1B"},
+		{1023, "1023B"},
+		{1024, "1KiB"},
+		{1536, "1.5KiB"},
+		{1024 * 1024, "1MiB"},
+		{16 * 1024 * 1024 * 1024, "16GiB"},
+		{31*1024*1024*1024 + 512*1024*1024, "31.5GiB"},
+		{2 * 1024 * 1024 * 1024 * 1024, "2TiB"},
+	}
+
+	for _, tc := range tests {
+		if got := formatBytes(tc.n); got != tc.want {
+			t.Errorf("formatBytes(%d): want %q, got %q", tc.n, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/morikuni/aec"
+)
+
+// screen redraws output in place in a terminal, for --watch and jobs wait.
+type screen struct {
+	w *os.File
+
+	// lines and fits describe the output which was last drawn
+	lines int
+	fits  bool
+}
+
+// draw replaces the output which was last drawn with out.
+func (s *screen) draw(out []byte) {
+	s.erase()
+	s.w.Write(out)
+	s.lines = bytes.Count(out, []byte("\n"))
+	s.fits = fitsTerminal(s.w, out)
+}
+
+// erase removes the output which was last drawn. The cursor is moved up
+// over it when it fitted within the terminal, otherwise the screen is
+// cleared, since lines which wrapped or scrolled off the top cannot be
+// reached by moving the cursor up.
+func (s *screen) erase() {
+	if s.lines == 0 {
+		return
+	}
+
+	if s.fits {
+		fmt.Fprint(s.w, aec.Up(uint(s.lines)).String()+aec.EraseDisplay(aec.EraseModes.Tail).String())
+	} else {
+		fmt.Fprint(s.w, aec.EraseDisplay(aec.EraseModes.All).String()+aec.Position(1, 1).String())
+	}
+	s.lines = 0
+}
+
+// fitsTerminal reports whether out can be shown without scrolling or
+// wrapping, it is false when the size of the terminal is not known.
+func fitsTerminal(f *os.File, out []byte) bool {
+	width, height := terminalSize(f)
+	if width == 0 || height == 0 {
+		return false
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(stripColour(out)), "\n"), "\n")
+
+	// The cursor is left on the line after the output
+	if len(lines) >= height {
+		return false
+	}
+
+	for _, line := range lines {
+		if utf8.RuneCountInString(line) > width {
+			return false
+		}
+	}
+
+	return true
+}
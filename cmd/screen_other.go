@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package cmd
+
+import "os"
+
+// terminalSize is not known on this platform, so the screen is cleared on
+// each redraw.
+func terminalSize(f *os.File) (int, int) {
+	return 0, 0
+}
@@ -1,20 +1,14 @@
 package cmd
 
 import (
-	"context"
-	"encoding/json"
 	"fmt"
-	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
-	"sort"
 	"strconv"
 	"strings"
 
-	"github.com/google/go-github/v76/github"
 	"github.com/spf13/cobra"
-	"golang.org/x/oauth2"
 )
 
 func makeSshConnect() *cobra.Command {
@@ -60,51 +54,13 @@ func runSshConnectE(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: pat},
-	)
-	tc := oauth2.NewClient(ctx, ts)
+	ctx := cmd.Context()
 
-	client := github.NewClient(tc)
-
-	user, _, err := client.Users.Get(ctx, "")
+	onlyActor, err := listSSHSessions(cmd, pat)
 	if err != nil {
 		return err
 	}
 
-	login := user.GetLogin()
-
-	u, _ := url.Parse(SshGw)
-	u.Path = "/list"
-	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Accept", "application/json")
-
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer res.Body.Close()
-
-	sessions := []sshSession{}
-	if err = json.NewDecoder(res.Body).Decode(&sessions); err != nil {
-		return err
-	}
-
-	onlyActor := []sshSession{}
-	for _, session := range sessions {
-		if session.Actor == login {
-			onlyActor = append(onlyActor, session)
-		}
-	}
-
-	sort.Slice(onlyActor, func(i, j int) bool {
-		return onlyActor[i].ConnectedAt > onlyActor[j].ConnectedAt
-	})
-
 	if len(onlyActor) == 0 {
 		return fmt.Errorf("no sessions found")
 	}
@@ -1,20 +1,15 @@
 package cmd
 
 import (
-	"bytes"
-	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/url"
-	"os"
 	"sort"
 	"strconv"
 	"time"
 
-	"github.com/google/go-github/v76/github"
-	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
-	"golang.org/x/oauth2"
 )
 
 const SshGw = "https://sshgw.actuated.dev"
@@ -26,7 +21,7 @@ func makeSshList() *cobra.Command {
 		Short:   "List SSH sessions",
 	}
 
-	cmd.Flags().BoolP("json", "j", false, "Request output in JSON format")
+	addOutputFlags(cmd)
 
 	cmd.RunE = runSshListE
 
@@ -40,52 +35,102 @@ func runSshListE(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: pat},
-	)
-	tc := oauth2.NewClient(ctx, ts)
-
-	client := github.NewClient(tc)
+	output, err := getOutputFormat(cmd)
+	if err != nil {
+		return err
+	}
 
-	user, _, err := client.Users.Get(ctx, "")
+	onlyActor, err := listSSHSessions(cmd, pat)
 	if err != nil {
 		return err
 	}
 
-	login := user.GetLogin()
+	return printOutput(cmd.OutOrStdout(), output, onlyActor,
+		func(w io.Writer, wide bool) error {
+			header := []string{"No.", "Actor", "Hostname", "RX", "TX", "Connected"}
+			if wide {
+				header = append(header, "Port", "Connections", "Command")
+			}
+
+			rows := [][]string{}
+			for i, session := range onlyActor {
+				connectedAt, _ := time.Parse(time.RFC3339, session.ConnectedAt)
+				since := time.Since(connectedAt).Round(time.Second)
+				row := []string{
+					strconv.Itoa(i + 1),
+					session.Actor,
+					session.Hostname,
+					strconv.Itoa(session.Rx),
+					strconv.Itoa(session.Tx),
+					since.String(),
+				}
+				if wide {
+					row = append(row, strconv.Itoa(session.Port), strconv.Itoa(session.Connections), session.Command)
+				}
+				rows = append(rows, row)
+			}
+
+			renderTable(w, header, rows)
+			return nil
+		},
+		func() ([]string, [][]string) {
+			header := []string{"hostname", "actor", "port", "rx", "tx", "connections", "connected_at", "command"}
+			rows := [][]string{}
+			for _, session := range onlyActor {
+				rows = append(rows, []string{
+					session.Hostname,
+					session.Actor,
+					strconv.Itoa(session.Port),
+					strconv.Itoa(session.Rx),
+					strconv.Itoa(session.Tx),
+					strconv.Itoa(session.Connections),
+					session.ConnectedAt,
+					session.Command,
+				})
+			}
+			return header, rows
+		})
+}
 
-	u, _ := url.Parse(SshGw)
-	u.Path = "/list"
-	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+// listSSHSessions returns the sessions for the user who owns the token,
+// the most recent first.
+func listSSHSessions(cmd *cobra.Command, pat string) ([]sshSession, error) {
+	httpClient, err := newHTTPClient(cmd)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	req.Header.Set("Accept", "application/json")
 
-	res, err := http.DefaultClient.Do(req)
+	client, err := newGitHubClient(cmd, pat)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer res.Body.Close()
 
-	jsonFormat, err := cmd.Flags().GetBool("json")
+	ctx := cmd.Context()
+
+	user, _, err := client.Users.Get(ctx, "")
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	buf := bytes.NewBuffer(nil)
-	table := tablewriter.NewWriter(buf)
+	login := user.GetLogin()
 
-	table.SetHeader([]string{"No.", "Actor", "Hostname", "RX", "TX", "Connected"})
+	u, _ := url.Parse(SshGw)
+	u.Path = "/list"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
 
-	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
-	table.SetCenterSeparator("|")
-	table.SetAutoWrapText(false)
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
 
 	var sessions []sshSession
 	if err := json.NewDecoder(res.Body).Decode(&sessions); err != nil {
-		return err
+		return nil, err
 	}
 
 	onlyActor := []sshSession{}
@@ -99,34 +144,7 @@ func runSshListE(cmd *cobra.Command, args []string) error {
 		return onlyActor[i].ConnectedAt > onlyActor[j].ConnectedAt
 	})
 
-	for i, session := range onlyActor {
-		connectedAt, _ := time.Parse(time.RFC3339, session.ConnectedAt)
-		since := time.Since(connectedAt).Round(time.Second)
-		table.Append([]string{
-			strconv.Itoa(i + 1),
-			session.Actor,
-			session.Hostname,
-			strconv.Itoa(session.Rx),
-			strconv.Itoa(session.Tx),
-			since.String(),
-		})
-	}
-
-	if jsonFormat {
-		e := json.NewEncoder(os.Stdout)
-		e.SetIndent("", "  ")
-		if err := e.Encode(onlyActor); err != nil {
-			return err
-		}
-		return nil
-	}
-
-	table.Render()
-
-	cmd.Print(buf.String())
-
-	return nil
-
+	return onlyActor, nil
 }
 
 type sshSession struct {
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// encryptedTokenPrefix marks a token file written by "auth --encrypt"
+	encryptedTokenPrefix = "actuated-enc:v1:"
+
+	// passphraseEnv holds the passphrase for an encrypted token file
+	passphraseEnv = "ACTUATED_TOKEN_PASSPHRASE"
+
+	pbkdf2Iterations = 600000
+	saltSize         = 16
+	keySize          = 32
+)
+
+// readPatFile reads a token from filePath, decrypting it when it was saved
+// with "auth --encrypt". A file which can be read by other users is given
+// a warning and its permissions are changed to 0600.
+func readPatFile(filePath string) (string, error) {
+	filePath = os.ExpandEnv(filePath)
+
+	if info, err := os.Stat(filePath); err == nil && info.Mode().Perm()&0077 != 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %s can be read by other users (%s), changing permissions to 0600\n",
+			filePath, info.Mode().Perm())
+
+		if err := os.Chmod(filePath, 0600); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: unable to change permissions of %s: %s\n", filePath, err)
+		}
+	}
+
+	patData, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	pat := strings.TrimSpace(string(patData))
+
+	if strings.HasPrefix(pat, encryptedTokenPrefix) {
+		return decryptToken(pat)
+	}
+
+	return pat, nil
+}
+
+// writePatFile writes the token to filePath with permissions of 0600, when
+// encrypt is set the token is encrypted with the passphrase from
+// ACTUATED_TOKEN_PASSPHRASE.
+func writePatFile(filePath, token string, encrypt bool) error {
+	filePath = os.ExpandEnv(filePath)
+
+	data := token
+	if encrypt {
+		v, err := encryptToken(token)
+		if err != nil {
+			return err
+		}
+		data = v
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0700); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filePath, []byte(data), 0600); err != nil {
+		return err
+	}
+
+	// WriteFile keeps the permissions of an existing file
+	return os.Chmod(filePath, 0600)
+}
+
+// isEncryptedPatFile reports whether the token file was saved with
+// "auth --encrypt".
+func isEncryptedPatFile(filePath string) bool {
+	data, err := os.ReadFile(os.ExpandEnv(filePath))
+	if err != nil {
+		return false
+	}
+
+	return strings.HasPrefix(strings.TrimSpace(string(data)), encryptedTokenPrefix)
+}
+
+func tokenPassphrase() (string, error) {
+	passphrase := os.Getenv(passphraseEnv)
+	if len(passphrase) == 0 {
+		return "", fmt.Errorf("the token file is encrypted, set %s to the passphrase", passphraseEnv)
+	}
+	return passphrase, nil
+}
+
+func tokenCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, pbkdf2Iterations, keySize)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// encryptToken encrypts the token with AES-256-GCM, using a key derived
+// from the passphrase with PBKDF2.
+func encryptToken(token string) (string, error) {
+	passphrase := os.Getenv(passphraseEnv)
+	if len(passphrase) == 0 {
+		return "", fmt.Errorf("set %s to a passphrase to encrypt the token", passphraseEnv)
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	aead, err := tokenCipher(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nil, nonce, []byte(token), nil)
+
+	out := append(append(salt, nonce...), sealed...)
+
+	return encryptedTokenPrefix + base64.StdEncoding.EncodeToString(out), nil
+}
+
+func decryptToken(data string) (string, error) {
+	passphrase, err := tokenPassphrase()
+	if err != nil {
+		return "", err
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(data, encryptedTokenPrefix))
+	if err != nil {
+		return "", fmt.Errorf("unable to decode encrypted token: %w", err)
+	}
+
+	if len(raw) < saltSize {
+		return "", fmt.Errorf("encrypted token is too short")
+	}
+
+	salt := raw[:saltSize]
+	aead, err := tokenCipher(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+
+	rest := raw[saltSize:]
+	if len(rest) < aead.NonceSize() {
+		return "", fmt.Errorf("encrypted token is too short")
+	}
+
+	token, err := aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("unable to decrypt token, check %s", passphraseEnv)
+	}
+
+	return string(token), nil
+}
@@ -1,10 +1,7 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
-	"net/http"
-	"os"
 	"strings"
 	"time"
 
@@ -54,7 +51,7 @@ func runUpgradeE(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	staff, err := cmd.Flags().GetBool("staff")
+	staff, err := getStaff(cmd)
 	if err != nil {
 		return err
 	}
@@ -63,6 +60,7 @@ func runUpgradeE(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	owner = getOwner(owner)
 
 	force, err := cmd.Flags().GetBool("force")
 	if err != nil {
@@ -81,24 +79,18 @@ func runUpgradeE(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("pat is required")
 	}
 
-	c := pkg.NewClient(http.DefaultClient, os.Getenv("ACTUATED_URL"))
+	c, err := newClient(cmd)
+	if err != nil {
+		return err
+	}
 
-	var upgradeHosts []Host
+	var upgradeHosts []pkg.Runner
 	if allHosts {
 		includeImages := false
-		hosts, httpStatus, err := c.ListRunners(pat, owner, staff, includeImages, true)
+		hostsList, err := c.ListRunners(cmd.Context(), pat, owner, staff, includeImages)
 		if err != nil {
 			return err
 		}
-		if httpStatus != http.StatusOK {
-			return fmt.Errorf("unexpected status code: %d", httpStatus)
-		}
-
-		var hostsList []Host
-
-		if err := json.Unmarshal([]byte(hosts), &hostsList); err != nil {
-			return err
-		}
 
 		if len(hostsList) == 0 {
 			return fmt.Errorf("no hosts found")
@@ -107,7 +99,7 @@ func runUpgradeE(cmd *cobra.Command, args []string) error {
 	} else {
 
 		// AE: TODO call ListRunners to determine the values for: Reachable and Status.
-		upgradeHosts = []Host{
+		upgradeHosts = []pkg.Runner{
 			{
 				Name:      host,
 				Customer:  owner,
@@ -118,6 +110,11 @@ func runUpgradeE(cmd *cobra.Command, args []string) error {
 	}
 
 	for _, h := range upgradeHosts {
+		// Stop before starting the next upgrade if the user pressed Control+C
+		if err := cmd.Context().Err(); err != nil {
+			return fmt.Errorf("upgrade cancelled before: %s (%s): %w", h.Name, h.Customer, err)
+		}
+
 		st := time.Now()
 		fmt.Printf("Upgrading: %s (%s)\n", h.Name, h.Customer)
 
@@ -126,16 +123,11 @@ func runUpgradeE(cmd *cobra.Command, args []string) error {
 		} else if h.Status != "running" {
 			fmt.Printf("Can't upgrade: %s (%s), status: %s\n", h.Name, h.Customer, h.Status)
 		} else {
-			res, status, err := c.UpgradeAgent(pat, h.Customer, h.Name, force, staff)
+			res, status, err := c.UpgradeAgent(cmd.Context(), pat, h.Customer, h.Name, force, staff)
 			if err != nil {
 				return err
 			}
 
-			if status != http.StatusOK && status != http.StatusAccepted &&
-				status != http.StatusNoContent && status != http.StatusCreated {
-				return fmt.Errorf("unexpected status code: %d, error: %s", status, res)
-			}
-
 			fmt.Printf("Upgrade: %s (%s): %d (%dms)\n", h.Name, h.Customer, status, time.Since(st).Milliseconds())
 
 			if strings.TrimSpace(res) != "" {
@@ -147,10 +139,3 @@ func runUpgradeE(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
-
-type Host struct {
-	Name      string `json:"name"`
-	Customer  string `json:"customer"`
-	Reachable bool   `json:"reachable"`
-	Status    string `json:"status"`
-}
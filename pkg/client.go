@@ -2,6 +2,7 @@ package pkg
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,119 +10,149 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 	"time"
 )
 
+// Client calls the actuated API, each request passes through a pipeline of
+// Middleware before being sent by the http.Client.
 type Client struct {
-	httpClient *http.Client
-	baseURL    string
+	httpClient  *http.Client
+	baseURL     string
+	retryPolicy RetryPolicy
+	userAgent   string
+	debug       io.Writer
+	metrics     func(RequestMetrics)
+	middleware  []Middleware
 }
 
-func NewClient(httpClient *http.Client, baseURL string) *Client {
-	return &Client{
-		httpClient: httpClient,
-		baseURL:    baseURL,
+// Option configures optional behaviour of the Client.
+type Option func(*Client)
+
+// WithRetryPolicy sets the policy for retrying transient failures, by
+// default requests are not retried.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = policy
 	}
 }
 
-func (c *Client) ListJobs(patStr string, owner string, staff bool, json bool) (string, int, error) {
+// WithUserAgent overrides the default User-Agent of actuated-cli/VERSION.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
 
-	u, _ := url.Parse(c.baseURL)
-	u.Path = "/api/v1/job-queue"
-	q := u.Query()
+// WithDebug traces each request and response to w, by default this is
+// enabled with os.Stderr by setting DEBUG=1 in the environment. Pass nil
+// to disable tracing.
+func WithDebug(w io.Writer) Option {
+	return func(c *Client) {
+		c.debug = w
+	}
+}
 
-	if staff {
-		q.Set("staff", "1")
+// WithMetrics calls record after each HTTP exchange with the API.
+func WithMetrics(record func(RequestMetrics)) Option {
+	return func(c *Client) {
+		c.metrics = record
 	}
+}
 
-	if len(owner) > 0 {
-		q.Set("owners", owner)
+// WithMiddleware adds middleware to the request pipeline, it runs after
+// the Authorization and User-Agent headers are set, and within any
+// retries, so that it sees every attempt.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(c *Client) {
+		c.middleware = append(c.middleware, middleware...)
 	}
+}
 
-	u.RawQuery = q.Encode()
+func NewClient(httpClient *http.Client, baseURL string, options ...Option) *Client {
+	c := &Client{
+		httpClient: httpClient,
+		baseURL:    baseURL,
+		userAgent:  DefaultUserAgent(),
+	}
 
-	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
-	if err != nil {
-		return "", http.StatusBadRequest, err
+	if os.Getenv("DEBUG") == "1" {
+		c.debug = os.Stderr
 	}
 
-	if json {
-		req.Header.Set("Accept", "application/json")
+	for _, o := range options {
+		o(c)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+patStr)
+	return c
+}
 
-	if os.Getenv("DEBUG") == "1" {
-		sanitised := http.Header{}
-		for k, v := range req.Header {
+// apiRequest describes a call to the API.
+type apiRequest struct {
+	pat   string
+	path  string
+	query url.Values
+
+	// json requests a JSON response instead of the server's text rendering
+	json bool
 
-			if k == "Authorization" {
-				v = []string{"redacted"}
-			}
-			sanitised[k] = v
-		}
+	// mutating calls change state, so are only retried when the
+	// RetryPolicy allows it
+	mutating bool
+}
 
-		fmt.Printf("URL %s\nHeaders: %v\n", u.String(), sanitised)
+// pipeline returns the chain of middleware for a request, ending with the
+// http.Client.
+func (c *Client) pipeline(r apiRequest) http.RoundTripper {
+	middleware := []Middleware{
+		UserAgentMiddleware(c.userAgent),
+		AuthMiddleware(r.pat),
 	}
 
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return "", http.StatusServiceUnavailable, err
+	if c.retryPolicy.MaxRetries > 0 && (!r.mutating || c.retryPolicy.RetryMutating) {
+		middleware = append(middleware, RetryMiddleware(c.retryPolicy))
 	}
 
-	var body []byte
-	if res.Body != nil {
-		defer res.Body.Close()
-		body, _ = io.ReadAll(res.Body)
+	if c.metrics != nil {
+		middleware = append(middleware, MetricsMiddleware(c.metrics))
 	}
 
-	return string(body), res.StatusCode, nil
-}
+	middleware = append(middleware, c.middleware...)
 
-func (c *Client) GetBuildIncreases(patStr string, owner string, startDate time.Time, staff bool, json bool) (string, int, error) {
+	if c.debug != nil {
+		middleware = append(middleware, DebugMiddleware(c.debug))
+	}
 
-	u, _ := url.Parse(c.baseURL)
-	u.Path = "/api/v1/job-increases"
-	q := u.Query()
+	return Chain(RoundTripperFunc(c.httpClient.Do), middleware...)
+}
 
-	if staff {
-		q.Set("staff", "1")
+// send makes a GET request to the API and returns the response body and
+// status code, or an APIError for a non-2xx status code.
+func (c *Client) send(ctx context.Context, r apiRequest) (string, int, error) {
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return "", 0, err
 	}
 
-	if len(owner) > 0 {
-		q.Set("owner", owner)
+	u.Path = r.path
+	q := u.Query()
+	for k, v := range r.query {
+		q[k] = v
 	}
-	q.Add("startDate", startDate.Format("2006-01-02"))
-
 	u.RawQuery = q.Encode()
 
-	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
-		return "", http.StatusBadRequest, err
+		return "", 0, err
 	}
 
-	if json {
+	if r.json {
 		req.Header.Set("Accept", "application/json")
 	}
 
-	req.Header.Set("Authorization", "Bearer "+patStr)
-
-	if os.Getenv("DEBUG") == "1" {
-		sanitised := http.Header{}
-		for k, v := range req.Header {
-
-			if k == "Authorization" {
-				v = []string{"redacted"}
-			}
-			sanitised[k] = v
-		}
-
-		fmt.Printf("URL %s\nHeaders: %v\n", u.String(), sanitised)
-	}
-
-	res, err := c.httpClient.Do(req)
+	res, err := c.pipeline(r).RoundTrip(req)
 	if err != nil {
-		return "", http.StatusServiceUnavailable, err
+		return "", 0, err
 	}
 
 	var body []byte
@@ -130,123 +161,176 @@ func (c *Client) GetBuildIncreases(patStr string, owner string, startDate time.T
 		body, _ = io.ReadAll(res.Body)
 	}
 
+	if err := checkResponse(req, res, body); err != nil {
+		return string(body), res.StatusCode, err
+	}
+
 	return string(body), res.StatusCode, nil
 }
 
-func (c *Client) ListRunners(patStr string, owner string, staff, images, json bool) (string, int, error) {
+// ListJobs returns the queued and in_progress jobs for the owner, or for all
+// authorized organisations when owner is empty.
+func (c *Client) ListJobs(ctx context.Context, patStr string, owner string, staff bool) ([]JobStatus, error) {
+	res, _, err := c.ListJobsRaw(ctx, patStr, owner, staff, true)
+	if err != nil {
+		return nil, err
+	}
 
-	u, _ := url.Parse(c.baseURL)
-	u.Path = "/api/v1/runners"
-	q := u.Query()
+	var jobs []JobStatus
+	if err := json.Unmarshal([]byte(res), &jobs); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// ListJobsRaw returns the undecoded response body for the job queue, set json
+// to false to receive the server's text rendering.
+func (c *Client) ListJobsRaw(ctx context.Context, patStr string, owner string, staff bool, json bool) (string, int, error) {
+
+	q := url.Values{}
 
 	if staff {
 		q.Set("staff", "1")
 	}
 
-	if images {
-		q.Set("images", "1")
-	}
-
 	if len(owner) > 0 {
-		q.Set("owner", owner)
-		log.Printf("Requesting runners for owner %s", owner)
+		q.Set("owners", owner)
 	}
 
-	u.RawQuery = q.Encode()
+	return c.send(ctx, apiRequest{
+		pat:   patStr,
+		path:  "/api/v1/job-queue",
+		query: q,
+		json:  json,
+	})
+}
 
-	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+// GetBuildIncreases returns the build increases for the owner since startDate.
+func (c *Client) GetBuildIncreases(ctx context.Context, patStr string, owner string, startDate time.Time, staff bool) (*Increases, error) {
+	res, _, err := c.GetBuildIncreasesRaw(ctx, patStr, owner, startDate, staff, true)
 	if err != nil {
-		return "", http.StatusBadRequest, err
+		return nil, err
 	}
 
-	if json {
-		req.Header.Set("Accept", "application/json")
+	increases := &Increases{
+		StartDate: startDate,
+	}
+	if err := json.Unmarshal([]byte(res), &increases.Data); err != nil {
+		return nil, err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+patStr)
+	return increases, nil
+}
 
-	if os.Getenv("DEBUG") == "1" {
-		sanitised := http.Header{}
-		for k, v := range req.Header {
+// GetBuildIncreasesRaw returns the undecoded response body for build increases.
+func (c *Client) GetBuildIncreasesRaw(ctx context.Context, patStr string, owner string, startDate time.Time, staff bool, json bool) (string, int, error) {
 
-			if k == "Authorization" {
-				v = []string{"redacted"}
-			}
-			sanitised[k] = v
-		}
+	q := url.Values{}
 
-		fmt.Printf("URL %s\nHeaders: %v\n", u.String(), sanitised)
+	if staff {
+		q.Set("staff", "1")
 	}
 
-	res, err := c.httpClient.Do(req)
+	if len(owner) > 0 {
+		q.Set("owner", owner)
+	}
+	q.Add("startDate", startDate.Format("2006-01-02"))
+
+	return c.send(ctx, apiRequest{
+		pat:   patStr,
+		path:  "/api/v1/job-increases",
+		query: q,
+		json:  json,
+	})
+}
+
+// ListRunners returns the servers running the agent for the owner.
+func (c *Client) ListRunners(ctx context.Context, patStr string, owner string, staff, images bool) ([]Runner, error) {
+	res, _, err := c.ListRunnersRaw(ctx, patStr, owner, staff, images, true)
 	if err != nil {
-		return "", http.StatusBadRequest, err
+		return nil, err
 	}
 
-	var body []byte
-	if res.Body != nil {
-		defer res.Body.Close()
-		body, _ = io.ReadAll(res.Body)
+	var runners []Runner
+	if err := json.Unmarshal([]byte(res), &runners); err != nil {
+		return nil, err
 	}
 
-	return string(body), res.StatusCode, nil
+	return runners, nil
 }
 
-func (c *Client) Repair(patStr string, owner string, staff bool) (string, int, error) {
+// ListRunnersRaw returns the undecoded response body for the runners, set json
+// to false to receive the server's text rendering.
+func (c *Client) ListRunnersRaw(ctx context.Context, patStr string, owner string, staff, images, json bool) (string, int, error) {
 
-	u, _ := url.Parse(c.baseURL)
-	u.Path = "/api/v1/repair"
-	q := u.Query()
+	q := url.Values{}
 
 	if staff {
 		q.Set("staff", "1")
 	}
 
-	q.Set("owner", owner)
-	u.RawQuery = q.Encode()
+	if images {
+		q.Set("images", "1")
+	}
 
-	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if len(owner) > 0 {
+		q.Set("owner", owner)
+		log.Printf("Requesting runners for owner %s", owner)
+	}
+
+	return c.send(ctx, apiRequest{
+		pat:   patStr,
+		path:  "/api/v1/runners",
+		query: q,
+		json:  json,
+	})
+}
+
+// Repair schedules additional VMs for the queued jobs of the owner. Older
+// servers reply with text, which is returned as the Message with no VMs.
+func (c *Client) Repair(ctx context.Context, patStr string, owner string, staff bool) (*RepairResult, error) {
+	res, _, err := c.RepairRaw(ctx, patStr, owner, staff)
 	if err != nil {
-		return "", http.StatusBadRequest, err
+		return nil, err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+patStr)
+	res = strings.TrimSpace(res)
+	result := &RepairResult{}
+	if err := json.Unmarshal([]byte(res), result); err != nil {
+		return &RepairResult{Message: res}, nil
+	}
 
-	if os.Getenv("DEBUG") == "1" {
-		sanitised := http.Header{}
-		for k, v := range req.Header {
+	return result, nil
+}
 
-			if k == "Authorization" {
-				v = []string{"redacted"}
-			}
-			sanitised[k] = v
-		}
+// RepairRaw returns the undecoded response body from a repair request.
+func (c *Client) RepairRaw(ctx context.Context, patStr string, owner string, staff bool) (string, int, error) {
 
-		fmt.Printf("URL %s\nHeaders: %v\n", u.String(), sanitised)
-	}
+	q := url.Values{}
 
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return "", http.StatusBadRequest, err
+	if staff {
+		q.Set("staff", "1")
 	}
 
-	var body []byte
-	if res.Body != nil {
-		defer res.Body.Close()
-		body, _ = io.ReadAll(res.Body)
-	}
+	q.Set("owner", owner)
 
-	return string(body), res.StatusCode, nil
+	return c.send(ctx, apiRequest{
+		pat:      patStr,
+		path:     "/api/v1/repair",
+		query:    q,
+		json:     true,
+		mutating: true,
+	})
 }
 
-func (c *Client) GetLogs(patStr, owner, host, id string, age time.Duration, staff bool) (string, int, error) {
+// GetLogs returns the logs of a VM as text, or of all the VMs on the host
+// when id is empty. Logs are plain text, so there is no typed variant.
+func (c *Client) GetLogs(ctx context.Context, patStr, owner, host, id string, age time.Duration, staff bool) (string, int, error) {
 
 	mins := int(age.Minutes())
 
-	u, _ := url.Parse(c.baseURL)
-	u.Path = "/api/v1/logs"
-
-	q := u.Query()
+	q := url.Values{}
 	q.Set("owner", owner)
 	q.Set("host", host)
 	q.Set("age", fmt.Sprintf("%dm", mins))
@@ -258,116 +342,55 @@ func (c *Client) GetLogs(patStr, owner, host, id string, age time.Duration, staf
 		q.Set("staff", "1")
 	}
 
-	u.RawQuery = q.Encode()
-
-	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
-	if err != nil {
-		return "", http.StatusBadRequest, err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+patStr)
-
-	if os.Getenv("DEBUG") == "1" {
-		sanitised := http.Header{}
-		for k, v := range req.Header {
-
-			if k == "Authorization" {
-				v = []string{"redacted"}
-			}
-			sanitised[k] = v
-		}
-
-		fmt.Printf("URL %s\nHeaders: %v\n", u.String(), sanitised)
-	}
-
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return "", http.StatusBadRequest, err
-	}
-
-	var body []byte
-	if res.Body != nil {
-		defer res.Body.Close()
-		body, _ = io.ReadAll(res.Body)
-	}
-
-	return string(body), res.StatusCode, nil
+	return c.send(ctx, apiRequest{
+		pat:   patStr,
+		path:  "/api/v1/logs",
+		query: q,
+	})
 }
 
-func (c *Client) GetMetering(patStr, owner, host, id string, staff bool) (string, int, error) {
+// GetMetering returns the metering snapshot of a VM as indented JSON, its
+// fields depend on the version of the agent, so there is no typed variant.
+func (c *Client) GetMetering(ctx context.Context, patStr, owner, host, id string, staff bool) (string, int, error) {
 
-	u, _ := url.Parse(c.baseURL)
-	u.Path = "/api/v1/metering"
-
-	q := u.Query()
+	q := url.Values{}
 	q.Set("owner", owner)
 	q.Set("host", host)
 
 	if len(id) > 0 {
 		q.Set("id", id)
 	} else {
-		return "", http.StatusBadRequest, fmt.Errorf("id is required")
+		return "", 0, fmt.Errorf("id is required")
 	}
 
 	if staff {
 		q.Set("staff", "1")
 	}
 
-	u.RawQuery = q.Encode()
-
-	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	res, status, err := c.send(ctx, apiRequest{
+		pat:   patStr,
+		path:  "/api/v1/metering",
+		query: q,
+	})
 	if err != nil {
-		return "", http.StatusBadRequest, err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+patStr)
-
-	if os.Getenv("DEBUG") == "1" {
-		sanitised := http.Header{}
-		for k, v := range req.Header {
-
-			if k == "Authorization" {
-				v = []string{"redacted"}
-			}
-			sanitised[k] = v
-		}
-
-		fmt.Printf("URL %s\nHeaders: %v\n", u.String(), sanitised)
-	}
-
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return "", http.StatusBadRequest, err
-	}
-
-	var body []byte
-	if res.Body != nil {
-		defer res.Body.Close()
-		body, _ = io.ReadAll(res.Body)
-	}
-
-	if res.StatusCode != http.StatusOK &&
-		res.StatusCode != http.StatusAccepted {
-		return string(body), res.StatusCode, nil
+		return res, status, err
 	}
 
 	var prettyJSON bytes.Buffer
 
-	if err = json.Indent(&prettyJSON, []byte(body), "", "  "); err != nil {
-		return "", http.StatusBadRequest, err
+	if err = json.Indent(&prettyJSON, []byte(res), "", "  "); err != nil {
+		return "", 0, err
 	}
 
-	return prettyJSON.String(), res.StatusCode, nil
+	return prettyJSON.String(), status, nil
 }
 
-func (c *Client) GetAgentLogs(patStr, owner, host string, age time.Duration, staff bool) (string, int, error) {
+// GetAgentLogs returns the logs of the agent's service on the host as text.
+func (c *Client) GetAgentLogs(ctx context.Context, patStr, owner, host string, age time.Duration, staff bool) (string, int, error) {
 
 	mins := int(age.Minutes())
 
-	u, _ := url.Parse(c.baseURL)
-	u.Path = "/api/v1/service"
-
-	q := u.Query()
+	q := url.Values{}
 	q.Set("owner", owner)
 	q.Set("host", host)
 	q.Set("age", fmt.Sprintf("%dm", mins))
@@ -376,50 +399,20 @@ func (c *Client) GetAgentLogs(patStr, owner, host string, age time.Duration, sta
 		q.Set("staff", "1")
 	}
 
-	u.RawQuery = q.Encode()
-
-	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
-	if err != nil {
-		return "", http.StatusBadRequest, err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+patStr)
-
-	if os.Getenv("DEBUG") == "1" {
-		sanitised := http.Header{}
-		for k, v := range req.Header {
-
-			if k == "Authorization" {
-				v = []string{"redacted"}
-			}
-			sanitised[k] = v
-		}
-
-		fmt.Printf("URL %s\nHeaders: %v\n", u.String(), sanitised)
-	}
-
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return "", http.StatusBadRequest, err
-	}
-
-	var body []byte
-	if res.Body != nil {
-		defer res.Body.Close()
-		body, _ = io.ReadAll(res.Body)
-	}
-
-	return string(body), res.StatusCode, nil
+	return c.send(ctx, apiRequest{
+		pat:   patStr,
+		path:  "/api/v1/service",
+		query: q,
+	})
 }
 
-func (c *Client) GetControllerLogs(patStr, outputFormat string, age time.Duration) (string, int, error) {
+// GetControllerLogs returns the logs of the controller, in the format given
+// by outputFormat.
+func (c *Client) GetControllerLogs(ctx context.Context, patStr, outputFormat string, age time.Duration) (string, int, error) {
 
 	mins := int(age.Minutes())
 
-	u, _ := url.Parse(c.baseURL)
-	u.Path = "/api/v1/controller/logs"
-
-	q := u.Query()
+	q := url.Values{}
 
 	q.Set("age", fmt.Sprintf("%dm", mins))
 
@@ -427,48 +420,18 @@ func (c *Client) GetControllerLogs(patStr, outputFormat string, age time.Duratio
 		q.Set("output", outputFormat)
 	}
 
-	u.RawQuery = q.Encode()
-
-	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
-	if err != nil {
-		return "", http.StatusBadRequest, err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+patStr)
-
-	if os.Getenv("DEBUG") == "1" {
-		sanitised := http.Header{}
-		for k, v := range req.Header {
-
-			if k == "Authorization" {
-				v = []string{"redacted"}
-			}
-			sanitised[k] = v
-		}
-
-		fmt.Printf("URL %s\nHeaders: %v\n", u.String(), sanitised)
-	}
-
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return "", http.StatusBadRequest, err
-	}
-
-	var body []byte
-	if res.Body != nil {
-		defer res.Body.Close()
-		body, _ = io.ReadAll(res.Body)
-	}
-
-	return string(body), res.StatusCode, nil
+	return c.send(ctx, apiRequest{
+		pat:   patStr,
+		path:  "/api/v1/controller/logs",
+		query: q,
+	})
 }
 
-func (c *Client) UpgradeAgent(patStr, owner, host string, force bool, staff bool) (string, int, error) {
-
-	u, _ := url.Parse(c.baseURL)
-	u.Path = "/api/v1/upgrade"
+// UpgradeAgent requests an upgrade of the agent on the host, the response
+// body is a message for the user rather than a JSON document.
+func (c *Client) UpgradeAgent(ctx context.Context, patStr, owner, host string, force bool, staff bool) (string, int, error) {
 
-	q := u.Query()
+	q := url.Values{}
 	q.Set("owner", owner)
 	q.Set("host", host)
 
@@ -480,48 +443,19 @@ func (c *Client) UpgradeAgent(patStr, owner, host string, force bool, staff bool
 
 	}
 
-	u.RawQuery = q.Encode()
-
-	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
-	if err != nil {
-		return "", http.StatusBadRequest, err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+patStr)
-
-	if os.Getenv("DEBUG") == "1" {
-		sanitised := http.Header{}
-		for k, v := range req.Header {
-
-			if k == "Authorization" {
-				v = []string{"redacted"}
-			}
-			sanitised[k] = v
-		}
-
-		fmt.Printf("URL %s\nHeaders: %v\n", u.String(), sanitised)
-	}
-
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return "", http.StatusBadRequest, err
-	}
-
-	var body []byte
-	if res.Body != nil {
-		defer res.Body.Close()
-		body, _ = io.ReadAll(res.Body)
-	}
-
-	return string(body), res.StatusCode, nil
+	return c.send(ctx, apiRequest{
+		pat:      patStr,
+		path:     "/api/v1/upgrade",
+		query:    q,
+		mutating: true,
+	})
 }
 
-func (c *Client) RestartAgent(patStr, owner, host string, reboot bool, staff bool) (string, int, error) {
+// RestartAgent requests a restart of the agent, or a reboot of the host,
+// the response body is a message for the user.
+func (c *Client) RestartAgent(ctx context.Context, patStr, owner, host string, reboot bool, staff bool) (string, int, error) {
 
-	u, _ := url.Parse(c.baseURL)
-	u.Path = "/api/v1/restart"
-
-	q := u.Query()
+	q := url.Values{}
 	q.Set("owner", owner)
 	q.Set("host", host)
 
@@ -533,48 +467,19 @@ func (c *Client) RestartAgent(patStr, owner, host string, reboot bool, staff boo
 
 	}
 
-	u.RawQuery = q.Encode()
-
-	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
-	if err != nil {
-		return "", http.StatusBadRequest, err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+patStr)
-
-	if os.Getenv("DEBUG") == "1" {
-		sanitised := http.Header{}
-		for k, v := range req.Header {
-
-			if k == "Authorization" {
-				v = []string{"redacted"}
-			}
-			sanitised[k] = v
-		}
-
-		fmt.Printf("URL %s\nHeaders: %v\n", u.String(), sanitised)
-	}
-
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return "", http.StatusBadRequest, err
-	}
-
-	var body []byte
-	if res.Body != nil {
-		defer res.Body.Close()
-		body, _ = io.ReadAll(res.Body)
-	}
-
-	return string(body), res.StatusCode, nil
+	return c.send(ctx, apiRequest{
+		pat:      patStr,
+		path:     "/api/v1/restart",
+		query:    q,
+		mutating: true,
+	})
 }
 
-func (c *Client) DisableAgent(patStr, owner, host string, staff bool) (string, int, error) {
+// DisableAgent stops the host from taking new jobs, the response body is a
+// message for the user.
+func (c *Client) DisableAgent(ctx context.Context, patStr, owner, host string, staff bool) (string, int, error) {
 
-	u, _ := url.Parse(c.baseURL)
-	u.Path = "/api/v1/disable"
-
-	q := u.Query()
+	q := url.Values{}
 	q.Set("owner", owner)
 	q.Set("host", host)
 
@@ -582,38 +487,10 @@ func (c *Client) DisableAgent(patStr, owner, host string, staff bool) (string, i
 		q.Set("staff", "1")
 	}
 
-	u.RawQuery = q.Encode()
-
-	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
-	if err != nil {
-		return "", http.StatusBadRequest, err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+patStr)
-
-	if os.Getenv("DEBUG") == "1" {
-		sanitised := http.Header{}
-		for k, v := range req.Header {
-
-			if k == "Authorization" {
-				v = []string{"redacted"}
-			}
-			sanitised[k] = v
-		}
-
-		fmt.Printf("URL %s\nHeaders: %v\n", u.String(), sanitised)
-	}
-
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return "", http.StatusBadRequest, err
-	}
-
-	var body []byte
-	if res.Body != nil {
-		defer res.Body.Close()
-		body, _ = io.ReadAll(res.Body)
-	}
-
-	return string(body), res.StatusCode, nil
+	return c.send(ctx, apiRequest{
+		pat:      patStr,
+		path:     "/api/v1/disable",
+		query:    q,
+		mutating: true,
+	})
 }
@@ -0,0 +1,109 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrUnauthorized matches an APIError with a 401 status code, the token
+	// is missing, invalid or has been revoked.
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrForbidden matches an APIError with a 403 status code, the token is
+	// valid, but has no access to the requested owner or host.
+	ErrForbidden = errors.New("forbidden")
+
+	// ErrNotFound matches an APIError with a 404 status code.
+	ErrNotFound = errors.New("not found")
+
+	// ErrRateLimited matches an APIError with a 429 status code.
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// APIError is returned when the actuated API responds with a status code
+// outside of the 2xx range. Use errors.Is with one of the Err* sentinels
+// to branch on the type of failure.
+type APIError struct {
+	// StatusCode is the HTTP status code returned by the server.
+	StatusCode int
+
+	// Body is the response body, which usually explains the error.
+	Body string
+
+	// Method and Endpoint identify the request i.e. GET /api/v1/job-queue
+	Method   string
+	Endpoint string
+
+	// RequestID is the server's identifier for the request, if it sent one.
+	RequestID string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("unexpected status code: %d from %s %s", e.StatusCode, e.Method, e.Endpoint)
+
+	if body := strings.TrimSpace(e.Body); len(body) > 0 {
+		msg += ", message: " + body
+	}
+
+	if len(e.RequestID) > 0 {
+		msg += ", request ID: " + e.RequestID
+	}
+
+	return msg
+}
+
+// Is reports whether the status code of the error matches one of the
+// Err* sentinels.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+
+	return false
+}
+
+// IsUnauthorized reports whether err was caused by a 401 from the API.
+func IsUnauthorized(err error) bool {
+	return errors.Is(err, ErrUnauthorized)
+}
+
+// IsForbidden reports whether err was caused by a 403 from the API.
+func IsForbidden(err error) bool {
+	return errors.Is(err, ErrForbidden)
+}
+
+// IsNotFound reports whether err was caused by a 404 from the API.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// IsRateLimited reports whether err was caused by a 429 from the API.
+func IsRateLimited(err error) bool {
+	return errors.Is(err, ErrRateLimited)
+}
+
+// checkResponse returns an APIError when the response's status code is
+// outside of the 2xx range.
+func checkResponse(req *http.Request, res *http.Response, body []byte) error {
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+
+	return &APIError{
+		StatusCode: res.StatusCode,
+		Body:       string(body),
+		Method:     req.Method,
+		Endpoint:   req.URL.Path,
+		RequestID:  res.Header.Get("X-Request-Id"),
+	}
+}
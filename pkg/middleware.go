@@ -0,0 +1,228 @@
+package pkg
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Middleware wraps the next http.RoundTripper in the Client's request
+// pipeline, to add headers, sign requests, record metrics and so forth.
+//
+// A Middleware must not modify the request it is given, clone it first
+// with req.Clone(req.Context()).
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to a http.RoundTripper.
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Chain wraps next with the middleware, so that the first middleware given
+// is the first to see each request.
+func Chain(next http.RoundTripper, middleware ...Middleware) http.RoundTripper {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		next = middleware[i](next)
+	}
+	return next
+}
+
+// HeaderMiddleware sets a header on each request.
+func HeaderMiddleware(key, value string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.Header.Set(key, value)
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// AuthMiddleware sets a bearer token in the Authorization header.
+func AuthMiddleware(token string) Middleware {
+	return HeaderMiddleware("Authorization", "Bearer "+token)
+}
+
+// UserAgentMiddleware sets the User-Agent header.
+func UserAgentMiddleware(userAgent string) Middleware {
+	return HeaderMiddleware("User-Agent", userAgent)
+}
+
+// DefaultUserAgent identifies the CLI and its version to the server.
+func DefaultUserAgent() string {
+	version := Version
+	if len(version) == 0 {
+		version = "dev"
+	}
+	return "actuated-cli/" + version
+}
+
+// maxDebugBody is the number of bytes of a response body printed by the
+// DebugMiddleware.
+const maxDebugBody = 2048
+
+// redactedHeaders are never printed by the DebugMiddleware.
+var redactedHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// redactedBody matches tokens in JSON and form encoded response bodies,
+// such as the access token returned by GitHub's device flow.
+var redactedBody = regexp.MustCompile(`("(?:access|refresh)_token"\s*:\s*")[^"]*(")|((?:access|refresh)_token=)[^&\s]*`)
+
+// DebugMiddleware prints a trace of each request to w, including the
+// method, URL, headers, an equivalent curl command, and the response's
+// status, headers, timing and the start of the body. Credentials are
+// redacted, and the curl command reads the token from $ACTUATED_PAT.
+func DebugMiddleware(w io.Writer) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			var reqBody []byte
+			if req.Body != nil && req.GetBody != nil {
+				if rc, err := req.GetBody(); err == nil {
+					reqBody, _ = io.ReadAll(rc)
+					rc.Close()
+				}
+			}
+
+			buf := &bytes.Buffer{}
+			fmt.Fprintf(buf, "> %s %s\n", req.Method, req.URL.String())
+			writeHeaders(buf, "> ", req.Header)
+			fmt.Fprintf(buf, "> %s\n", CurlCommand(req, reqBody))
+
+			st := time.Now()
+			res, err := next.RoundTrip(req)
+			duration := time.Since(st).Round(time.Millisecond)
+
+			if err != nil {
+				fmt.Fprintf(buf, "< error after %s: %s\n\n", duration, err)
+				w.Write(buf.Bytes())
+				return res, err
+			}
+
+			fmt.Fprintf(buf, "< %s (%s)\n", res.Status, duration)
+			writeHeaders(buf, "< ", res.Header)
+
+			if res.Body != nil {
+				body, readErr := io.ReadAll(res.Body)
+				res.Body.Close()
+				res.Body = io.NopCloser(bytes.NewReader(body))
+
+				if readErr != nil {
+					fmt.Fprintf(buf, "< error reading body: %s\n", readErr)
+				}
+
+				printed := redactedBody.ReplaceAll(body, []byte("${1}${3}redacted${2}"))
+				if len(printed) > maxDebugBody {
+					fmt.Fprintf(buf, "< %s\n< [truncated %d of %d bytes]\n", printed[:maxDebugBody], len(printed)-maxDebugBody, len(printed))
+				} else if len(printed) > 0 {
+					fmt.Fprintf(buf, "< %s\n", printed)
+				}
+			}
+			buf.WriteString("\n")
+
+			w.Write(buf.Bytes())
+
+			return res, nil
+		})
+	}
+}
+
+// writeHeaders prints headers in a stable order with credentials redacted.
+func writeHeaders(w io.Writer, prefix string, header http.Header) {
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := strings.Join(header[k], ", ")
+		if redactedHeaders[k] {
+			v = "redacted"
+		}
+		fmt.Fprintf(w, "%s%s: %s\n", prefix, k, v)
+	}
+}
+
+// CurlCommand returns a curl command which reproduces the request, with any
+// bearer token replaced by $ACTUATED_PAT so that it is safe to share.
+func CurlCommand(req *http.Request, body []byte) string {
+	parts := []string{"curl", "-X", req.Method}
+
+	keys := make([]string, 0, len(req.Header))
+	for k := range req.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		for _, v := range req.Header[k] {
+			if redactedHeaders[k] {
+				if k == "Authorization" && strings.HasPrefix(strings.ToLower(v), "bearer ") {
+					parts = append(parts, "-H", `"`+k+`: Bearer $ACTUATED_PAT"`)
+				}
+				continue
+			}
+			parts = append(parts, "-H", shellQuote(k+": "+v))
+		}
+	}
+
+	if len(body) > 0 {
+		parts = append(parts, "--data", shellQuote(string(body)))
+	}
+
+	parts = append(parts, shellQuote(req.URL.String()))
+
+	return strings.Join(parts, " ")
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// RequestMetrics describes a single HTTP exchange with the API.
+type RequestMetrics struct {
+	Method   string
+	Endpoint string
+
+	// StatusCode is 0 when Err is set.
+	StatusCode int
+	Duration   time.Duration
+	Err        error
+}
+
+// MetricsMiddleware calls record after each HTTP exchange, including each
+// attempt made when retrying.
+func MetricsMiddleware(record func(RequestMetrics)) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			st := time.Now()
+			res, err := next.RoundTrip(req)
+
+			m := RequestMetrics{
+				Method:   req.Method,
+				Endpoint: req.URL.Path,
+				Duration: time.Since(st),
+				Err:      err,
+			}
+			if res != nil {
+				m.StatusCode = res.StatusCode
+			}
+			record(m)
+
+			return res, err
+		})
+	}
+}
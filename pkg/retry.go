@@ -0,0 +1,138 @@
+package pkg
+
+import (
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how requests are retried after a transient failure
+// such as a connection error, a 429 or a 502, 503 or 504 status code.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt, 0
+	// disables retries.
+	MaxRetries int
+
+	// InitialBackoff is the wait before the first retry, it doubles for
+	// each subsequent retry, with jitter applied.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the wait between retries, including any wait
+	// requested by the server through a Retry-After header.
+	MaxBackoff time.Duration
+
+	// RetryMutating enables retries for calls which change state, such as
+	// RestartAgent or DisableAgent, which are never retried otherwise.
+	RetryMutating bool
+}
+
+// DefaultRetryPolicy retries up to 3 times, waiting between 0.5s and 10s.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries:     3,
+		InitialBackoff: time.Millisecond * 500,
+		MaxBackoff:     time.Second * 10,
+	}
+}
+
+// retryableStatus reports whether a status code is likely to be transient.
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff returns the wait before the given retry, starting at 1, using
+// the Retry-After header from res when present.
+func (p RetryPolicy) backoff(retry int, res *http.Response) time.Duration {
+	if res != nil {
+		if wait, ok := parseRetryAfter(res.Header.Get("Retry-After")); ok {
+			if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+				wait = p.MaxBackoff
+			}
+			return wait
+		}
+	}
+
+	wait := p.InitialBackoff << (retry - 1)
+	if wait <= 0 || (p.MaxBackoff > 0 && wait > p.MaxBackoff) {
+		wait = p.MaxBackoff
+	}
+
+	// Apply jitter so that many clients don't retry in lock-step
+	half := int64(wait / 2)
+	if half <= 0 {
+		return wait
+	}
+
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// parseRetryAfter parses a Retry-After header given as either a number
+// of seconds or a HTTP date.
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		wait := time.Until(t)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+
+	return 0, false
+}
+
+// RetryMiddleware retries requests which fail with a transient error
+// according to policy. The Client only adds it to the pipeline for
+// mutating calls when policy.RetryMutating is set.
+func RetryMiddleware(policy RetryPolicy) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			ctx := req.Context()
+			for retry := 0; ; retry++ {
+				res, err := next.RoundTrip(req.Clone(ctx))
+
+				if retry >= policy.MaxRetries {
+					return res, err
+				}
+
+				if err != nil {
+					// The caller cancelled the request or its deadline passed
+					if ctx.Err() != nil {
+						return res, err
+					}
+				} else if !retryableStatus(res.StatusCode) {
+					return res, nil
+				}
+
+				wait := policy.backoff(retry+1, res)
+
+				if res != nil && res.Body != nil {
+					io.Copy(io.Discard, res.Body)
+					res.Body.Close()
+				}
+
+				select {
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				case <-time.After(wait):
+				}
+			}
+		})
+	}
+}
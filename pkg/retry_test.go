@@ -0,0 +1,190 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"empty", "", 0, false},
+		{"seconds", "2", time.Second * 2, true},
+		{"zero", "0", 0, true},
+		{"negative", "-1", 0, false},
+		{"invalid", "soon", 0, false},
+		{"date in the past", "Mon, 02 Jan 2006 15:04:05 GMT", 0, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tc.value)
+			if got != tc.want || ok != tc.wantOK {
+				t.Errorf("%q: want %s, %v, got %s, %v", tc.value, tc.want, tc.wantOK, got, ok)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfterDate(t *testing.T) {
+	v := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+
+	got, ok := parseRetryAfter(v)
+	if !ok || got <= time.Second*50 || got > time.Minute {
+		t.Errorf("%q: want about 1m, got %s, %v", v, got, ok)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	retryAfter := func(v string) *http.Response {
+		return &http.Response{Header: http.Header{"Retry-After": []string{v}}}
+	}
+
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		retry   int
+		res     *http.Response
+		wantMin time.Duration
+		wantMax time.Duration
+	}{
+		{"Retry-After", RetryPolicy{InitialBackoff: time.Millisecond, MaxBackoff: time.Minute}, 1, retryAfter("5"), time.Second * 5, time.Second * 5},
+		{"Retry-After is capped", RetryPolicy{InitialBackoff: time.Millisecond, MaxBackoff: time.Second}, 1, retryAfter("5"), time.Second, time.Second},
+		{"invalid Retry-After is ignored", RetryPolicy{InitialBackoff: time.Second, MaxBackoff: time.Minute}, 1, retryAfter("soon"), time.Second / 2, time.Second},
+		{"first retry", RetryPolicy{InitialBackoff: time.Second, MaxBackoff: time.Minute}, 1, nil, time.Second / 2, time.Second},
+		{"doubles", RetryPolicy{InitialBackoff: time.Second, MaxBackoff: time.Minute}, 3, nil, time.Second * 2, time.Second * 4},
+		{"capped", RetryPolicy{InitialBackoff: time.Second, MaxBackoff: time.Second * 3}, 5, nil, time.Second * 3 / 2, time.Second * 3},
+		{"overflow is capped", RetryPolicy{InitialBackoff: time.Second, MaxBackoff: time.Second * 3}, 64, nil, time.Second * 3 / 2, time.Second * 3},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.policy.backoff(tc.retry, tc.res)
+			if got < tc.wantMin || got > tc.wantMax {
+				t.Errorf("want between %s and %s, got %s", tc.wantMin, tc.wantMax, got)
+			}
+		})
+	}
+}
+
+// fakeResponse is returned by fakeTransport, with an error when err is set
+type fakeResponse struct {
+	status     int
+	retryAfter string
+	err        error
+}
+
+// fakeTransport returns the responses in order, and the last one for every
+// attempt after that.
+func fakeTransport(responses []fakeResponse, attempts *int) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		r := responses[min(*attempts, len(responses)-1)]
+		*attempts++
+
+		if r.err != nil {
+			return nil, r.err
+		}
+
+		res := &http.Response{
+			StatusCode: r.status,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader("")),
+			Request:    req,
+		}
+		if len(r.retryAfter) > 0 {
+			res.Header.Set("Retry-After", r.retryAfter)
+		}
+		return res, nil
+	})
+}
+
+func TestRetryMiddleware(t *testing.T) {
+	policy := RetryPolicy{
+		MaxRetries:     3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond * 5,
+	}
+
+	connErr := errors.New("connection refused")
+
+	tests := []struct {
+		name         string
+		policy       RetryPolicy
+		responses    []fakeResponse
+		wantStatus   int
+		wantErr      bool
+		wantAttempts int
+	}{
+		{"success", policy, []fakeResponse{{status: 200}}, 200, false, 1},
+		{"not found is not retried", policy, []fakeResponse{{status: 404}}, 404, false, 1},
+		{"server error is not retried", policy, []fakeResponse{{status: 500}}, 500, false, 1},
+		{"503 then success", policy, []fakeResponse{{status: 503}, {status: 503}, {status: 200}}, 200, false, 3},
+		{"429 then success", policy, []fakeResponse{{status: 429}, {status: 200}}, 200, false, 2},
+		{"502 and 504 then success", policy, []fakeResponse{{status: 502}, {status: 504}, {status: 200}}, 200, false, 3},
+		{"connection error then success", policy, []fakeResponse{{err: connErr}, {status: 200}}, 200, false, 2},
+		{"gives up after MaxRetries", policy, []fakeResponse{{status: 503}}, 503, false, 4},
+		{"gives up on connection errors", policy, []fakeResponse{{err: connErr}}, 0, true, 4},
+		{"retries disabled", RetryPolicy{}, []fakeResponse{{status: 503}, {status: 200}}, 503, false, 1},
+		{"Retry-After of zero", RetryPolicy{MaxRetries: 1, InitialBackoff: time.Hour}, []fakeResponse{{status: 429, retryAfter: "0"}, {status: 200}}, 200, false, 2},
+		{"Retry-After is capped by MaxBackoff", RetryPolicy{MaxRetries: 1, MaxBackoff: time.Millisecond}, []fakeResponse{{status: 503, retryAfter: "3600"}, {status: 200}}, 200, false, 2},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			attempts := 0
+			rt := Chain(fakeTransport(tc.responses, &attempts), RetryMiddleware(tc.policy))
+
+			req, err := http.NewRequest(http.MethodGet, "http://actuated.example.com/api/v1/jobs", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+			defer cancel()
+
+			res, err := rt.RoundTrip(req.WithContext(ctx))
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("want error: %v, got: %v", tc.wantErr, err)
+			}
+
+			if err == nil && res.StatusCode != tc.wantStatus {
+				t.Errorf("want status %d, got %d", tc.wantStatus, res.StatusCode)
+			}
+
+			if attempts != tc.wantAttempts {
+				t.Errorf("want %d attempts, got %d", tc.wantAttempts, attempts)
+			}
+		})
+	}
+}
+
+func TestRetryMiddlewareCancelled(t *testing.T) {
+	attempts := 0
+	policy := RetryPolicy{MaxRetries: 3, InitialBackoff: time.Hour}
+	rt := Chain(fakeTransport([]fakeResponse{{status: 503}}, &attempts), RetryMiddleware(policy))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://actuated.example.com/api/v1/jobs", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := rt.RoundTrip(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	if attempts != 1 {
+		t.Errorf("want 1 attempt, got %d", attempts)
+	}
+}
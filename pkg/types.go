@@ -0,0 +1,89 @@
+package pkg
+
+import (
+	"fmt"
+	"time"
+)
+
+// JobStatus is a queued or in_progress job from the build queue.
+type JobStatus struct {
+	JobID        int64  `json:"job_id"`
+	Owner        string `json:"owner"`
+	Repo         string `json:"repo"`
+	WorkflowName string `json:"workflow_name"`
+	JobName      string `json:"job_name"`
+	Actor        string `json:"actor,omitempty"`
+
+	RunnerName string   `json:"runner_name,omitempty"`
+	Status     string   `json:"status"`
+	Conclusion string   `json:"conclusion,omitempty"`
+	Labels     []string `json:"labels,omitempty"`
+	URL        string   `json:"url,omitempty"`
+
+	UpdatedAt   *time.Time `json:"updated_at"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+
+	AgentName string `json:"agent_name,omitempty"`
+
+	AverageRuntime time.Duration `json:"averageRuntime,omitempty"`
+
+	QueuedAt *time.Time `json:"queuedAt,omitempty"`
+}
+
+// URLField returns the GitHub URL for the job run, constructed client-side
+// from the owner, repo and job ID.
+func (j JobStatus) URLField() string {
+	return j.URLFieldForHost("github.com")
+}
+
+// URLFieldForHost returns the URL for the job run on a GitHub Enterprise
+// Server host such as github.example.com.
+func (j JobStatus) URLFieldForHost(host string) string {
+	return fmt.Sprintf("https://%s/%s%s/runs/%d", host, j.Owner+"/", j.Repo, j.JobID)
+}
+
+// Runner is a server running the actuated agent. The fields after Status
+// are optional, older versions of the API do not return them, so they are
+// left empty rather than treated as zero.
+type Runner struct {
+	Name      string `json:"name"`
+	Customer  string `json:"customer"`
+	Reachable bool   `json:"reachable"`
+	Status    string `json:"status"`
+
+	// CPUs and Memory (in bytes) are the capacity of the server, 0 when
+	// they are not known
+	CPUs   int   `json:"cpus,omitempty"`
+	Memory int64 `json:"memory,omitempty"`
+
+	// RunningVMs is the number of VMs running jobs on the server, nil when
+	// it is not known
+	RunningVMs *int `json:"runningVMs,omitempty"`
+
+	AgentVersion string `json:"agentVersion,omitempty"`
+
+	// Kernel and Rootfs are the images used for VMs, they are only
+	// returned when images are requested.
+	Kernel string `json:"kernel,omitempty"`
+	Rootfs string `json:"rootfs,omitempty"`
+}
+
+// Increases is the report of build increases for an organisation since
+// StartDate. The shape of the report is decided by the server, so it is
+// kept as decoded JSON in Data.
+type Increases struct {
+	StartDate time.Time   `json:"startDate"`
+	Data      interface{} `json:"data"`
+}
+
+// RepairResult is returned when a repair is scheduled for the build queue.
+type RepairResult struct {
+	// VMs is the number of VMs which were requeued, nil when the server
+	// did not return a count
+	VMs *int `json:"vms,omitempty"`
+
+	// Message is the response body when the server replied with text
+	// rather than JSON
+	Message string `json:"-"`
+}